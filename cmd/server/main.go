@@ -1,23 +1,41 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/GonzoDMX/rag-anywhere/internal/api"
+	"github.com/GonzoDMX/rag-anywhere/internal/store"
 	// "github.com/GonzoDMX/rag-anywhere/internal/ipc" // We will uncomment when we wire up Python
 )
 
 func main() {
+	allowOpenIncompatible := flag.Bool("allow-open-incompatible", false,
+		"permit read-only keyword/KG search on an incompatible database while it re-embeds in the background")
+	flag.Parse()
+
 	// 1. Setup Logger
 	logger := log.New(os.Stdout, "[RAG-SERVER] ", log.LstdFlags)
 
 	// 2. Initialize Dependencies (Placeholders for now)
 	logger.Println("Initializing SQLite...")
+	mgr, err := store.NewManager()
+	if err != nil {
+		logger.Fatalf("Failed to init store manager: %v", err)
+	}
+	api.SetManager(mgr)
+	api.SetAllowOpenIncompatible(*allowOpenIncompatible)
+	defer mgr.CloseAll()
+
 	logger.Println("Initializing Python Worker Pools...")
 	// embedPool := ipc.NewWorkerPool(...)
+	// vectorPool := ipc.NewWorkerPool(...)
+	// rerankPool := ipc.NewWorkerPool("scripts/rerank.py", ...) // only needed for hybrid search's "rerank" fusion mode
+	// mgr.VectorWorker = vectorPool // only needed for databases using the "faiss" vector_backend
+	// api.SetWorkerPools(embedPool, vectorPool, rerankPool)
 
 	// 3. Setup Router
 	mux := http.NewServeMux()
@@ -31,12 +49,15 @@ func main() {
 
 	// --- Documents ---
 	mux.HandleFunc("POST /api/v1/docs/add", api.HandleDocAdd)
-	mux.HandleFunc("POST /api/v1/docs/batch", api.HandleDocAddBatch)            // Start batch
-	mux.HandleFunc("GET /api/v1/docs/batch/{id}", api.HandleBatchStatus)        // Poll status
-	mux.HandleFunc("GET /api/v1/docs/batch/{id}/stream", api.HandleBatchEvents) // SSE Stream
-	mux.HandleFunc("POST /api/v1/docs/list", api.HandleDocList)                 // Search/Filter docs
-	mux.HandleFunc("POST /api/v1/docs/query", api.HandleDocQuery)               // Metadata query
-	mux.HandleFunc("GET /api/v1/docs/{id}", api.HandleDocGet)                   // Get Full Text
+	mux.HandleFunc("POST /api/v1/docs/estimate", api.HandleDocEstimate)            // Pre-flight token cost
+	mux.HandleFunc("POST /api/v1/docs/batch/estimate", api.HandleDocBatchEstimate) // Pre-flight token cost (batch)
+	mux.HandleFunc("POST /api/v1/docs/batch", api.HandleDocAddBatch)               // Start batch
+	mux.HandleFunc("GET /api/v1/docs/batch/{id}", api.HandleBatchStatus)           // Poll status
+	mux.HandleFunc("GET /api/v1/docs/batch/{id}/stream", api.HandleBatchEvents)    // SSE Stream
+	mux.HandleFunc("POST /api/v1/docs/batch/{id}/cancel", api.HandleBatchCancel)   // Cancel in-flight batch
+	mux.HandleFunc("POST /api/v1/docs/list", api.HandleDocList)                    // Search/Filter docs
+	mux.HandleFunc("POST /api/v1/docs/query", api.HandleDocQuery)                  // Metadata query
+	mux.HandleFunc("GET /api/v1/docs/{id}", api.HandleDocGet)                      // Get Full Text
 	mux.HandleFunc("DELETE /api/v1/docs/{id}", api.HandleDocRemove)
 
 	// --- Database ---
@@ -45,6 +66,9 @@ func main() {
 	mux.HandleFunc("GET /api/v1/db/list", api.HandleDBList)
 	mux.HandleFunc("GET /api/v1/db/info", api.HandleDBInfo)
 	mux.HandleFunc("DELETE /api/v1/db/{name}", api.HandleDBDelete)
+	mux.HandleFunc("POST /api/v1/db/{name}/migrate", api.HandleDBMigrate)      // Stream re-embed progress for a named DB
+	mux.HandleFunc("POST /api/v1/db/migrate", api.HandleDBMigrateStart)        // Start re-embed for the resolved DB (background)
+	mux.HandleFunc("GET /api/v1/db/migrate/status", api.HandleDBMigrateStatus) // Poll progress for the resolved DB
 
 	// --- Knowledge Graph ---
 	mux.HandleFunc("GET /api/v1/kg/entities", api.HandleKGListEntities)
@@ -62,8 +86,8 @@ func main() {
 	mux.HandleFunc("POST /api/v1/search/kg", api.HandleSearchKG)
 
 	// 4. Middleware Chain
-	// We wrap the entire mux with middleware (CORS, Logging)
-	handler := MiddlewareChain(mux, logger)
+	// We wrap the entire mux with middleware (CORS, Logging, per-request DB routing)
+	handler := MiddlewareChain(api.DBContext(mgr)(mux), logger)
 
 	// 5. Start Server
 	port := ":8080"
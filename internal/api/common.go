@@ -1,6 +1,7 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,8 +9,142 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/store"
 )
 
+// defaultDBName is the database handlers resolve to when a request doesn't
+// specify one via X-RAG-DB, ?db=, or a JSON "db" field.
+const defaultDBName = "default"
+
+// sharedManager is the process-wide store.Manager set by main.go via
+// SetManager. It backs DBContext's per-DB *sql.DB cache, so handlers
+// resolve to the same pooled connections the middleware opened.
+var sharedManager *store.Manager
+
+// allowOpenIncompatible mirrors the server's --allow-open-incompatible
+// flag. When false (the default), a database with a pending migration
+// (plan.Required()) is refused by every search handler (rejectIfDBUnavailable)
+// and by docs add/batch (rejectIfMigrationPending); the other handlers don't
+// check. When true, keyword/KG search may still read it while a re-embed
+// runs in the background; vector search stays blocked regardless, since its
+// embeddings are the thing that's wrong.
+var allowOpenIncompatible bool
+
+// SetAllowOpenIncompatible wires the --allow-open-incompatible startup flag.
+func SetAllowOpenIncompatible(v bool) {
+	allowOpenIncompatible = v
+}
+
+// SetManager wires the store.Manager used by DBContext middleware and by
+// handlers that need to open/list/create databases. Call once from
+// main.go during startup.
+func SetManager(mgr *store.Manager) {
+	sharedManager = mgr
+}
+
+// getManager returns the process-wide Manager, lazily creating one if
+// main.go hasn't called SetManager yet (keeps handlers usable in isolation,
+// e.g. from tests).
+func getManager() (*store.Manager, error) {
+	if sharedManager != nil {
+		return sharedManager, nil
+	}
+	return store.NewManager()
+}
+
+// dbFromRequest resolves the active *sql.DB for r: the DBContext-attached
+// handle if the middleware ran, otherwise a direct (uncached) open of
+// defaultDBName. Handlers should prefer this over reaching for a Manager
+// themselves.
+func dbFromRequest(r *http.Request) (*sql.DB, error) {
+	if handle, ok := store.FromContext(r.Context()); ok {
+		return handle.DB, nil
+	}
+	return openDefaultDB()
+}
+
+// openDefaultDB opens a handle to the default database's SQLite file
+// directly, bypassing the Manager's cache. Used as a fallback when a
+// request reaches a handler without having gone through DBContext.
+func openDefaultDB() (*sql.DB, error) {
+	mgr, err := getManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init store manager: %w", err)
+	}
+	dbPath := filepath.Join(mgr.GetDBPath(defaultDBName), "rag.db")
+	return sql.Open("sqlite3", dbPath)
+}
+
+// activeDBName returns the DB name resolved for r, falling back to
+// defaultDBName if DBContext didn't attach one.
+func activeDBName(r *http.Request) string {
+	if handle, ok := store.FromContext(r.Context()); ok {
+		return handle.Name
+	}
+	return defaultDBName
+}
+
+// rejectIfMigrationPending writes a 409 and returns true when the active
+// database's stamped model config no longer matches config.CurrentDefaults.
+// Write endpoints (docs/add, docs/batch) must call this first so nothing
+// gets embedded or chunked under a mismatched model while a migration is
+// pending. Returns false (and writes nothing) if the DB can't be found yet,
+// since that's a normal first-run state, not a migration hazard.
+func rejectIfMigrationPending(r *http.Request, w http.ResponseWriter) (rejected bool) {
+	mgr, err := getManager()
+	if err != nil {
+		return false
+	}
+
+	db, plan, err := mgr.OpenDatabase(activeDBName(r))
+	if err != nil {
+		return false
+	}
+	db.Close()
+
+	if plan.Required() {
+		errorResponse(w, http.StatusConflict, "Database has a pending migration; write endpoints are disabled until it completes")
+		return true
+	}
+	return false
+}
+
+// rejectIfDBUnavailable writes a 409 and returns true when r's active
+// database has a required migration pending and this handler isn't allowed
+// to read it anyway. readOnlyOK should be true for handlers that don't
+// depend on the embedding vectors (keyword/KG search) and false for
+// anything that does (semantic/hybrid search) — vector results are
+// meaningless mid re-embed even with --allow-open-incompatible set.
+func rejectIfDBUnavailable(r *http.Request, w http.ResponseWriter, readOnlyOK bool) (rejected bool) {
+	mgr, err := getManager()
+	if err != nil {
+		return false
+	}
+
+	db, plan, err := mgr.OpenDatabase(activeDBName(r))
+	if err != nil {
+		return false
+	}
+	db.Close()
+
+	if !plan.Required() {
+		return false
+	}
+	if allowOpenIncompatible && readOnlyOK {
+		return false
+	}
+
+	msg := "Database has a pending migration and is not readable"
+	if !allowOpenIncompatible {
+		msg += "; start the server with --allow-open-incompatible to permit read-only keyword/KG search while it migrates"
+	} else {
+		msg += " by this endpoint; only keyword/KG search is allowed while it migrates"
+	}
+	errorResponse(w, http.StatusConflict, msg)
+	return true
+}
+
 // jsonResponse sends a standard JSON response
 func jsonResponse(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
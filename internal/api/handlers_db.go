@@ -2,9 +2,35 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/config"
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
+	"github.com/GonzoDMX/rag-anywhere/internal/pipeline"
+	"github.com/GonzoDMX/rag-anywhere/internal/store"
 )
 
+// workerPools holds the Python worker pools shared across handlers in this
+// package (migration, token estimation, hybrid-search reranking, etc). nil
+// until main.go calls SetWorkerPools once the pools are started.
+var workerPools struct {
+	embed  ipc.Client
+	vector ipc.Client
+	rerank ipc.Client
+}
+
+// SetWorkerPools wires the embed/vector/rerank worker pools that handlers
+// in this package dispatch work to. rerank may be nil if the server isn't
+// running a cross-encoder sidecar, in which case FusionRerank requests
+// fail with a 503 rather than silently falling back to another mode.
+func SetWorkerPools(embed, vector, rerank ipc.Client) {
+	workerPools.embed = embed
+	workerPools.vector = vector
+	workerPools.rerank = rerank
+}
+
 // ==========================================
 // DATABASE OPERATIONS
 // ==========================================
@@ -18,37 +44,289 @@ func HandleDBCreate(w http.ResponseWriter, r *http.Request) {
 		errorResponse(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
+	if req.Name == "" {
+		errorResponse(w, http.StatusBadRequest, "Missing 'name' field")
+		return
+	}
+
+	mgr, err := getManager()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to init store manager: "+err.Error())
+		return
+	}
+
+	if err := mgr.CreateDatabase(req.Name, req.Description); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
 	jsonResponse(w, http.StatusCreated, StandardResponse{Success: true, Message: "Database created"})
 }
 
 // HandleDBUse - POST /api/v1/db/use
+// Kept for backwards compatibility with clients that set the active DB
+// explicitly rather than sending X-RAG-DB/?db= on every request; it only
+// validates the name exists since routing is now resolved per-request by
+// DBContext middleware.
 func HandleDBUse(w http.ResponseWriter, r *http.Request) {
 	var req DBUseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
+
+	mgr, err := getManager()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to init store manager: "+err.Error())
+		return
+	}
+
+	if _, err := mgr.OpenCached(req.Name); err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
 	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Message: "Switched to database " + req.Name})
 }
 
 // HandleDBList - GET /api/v1/db/list
 func HandleDBList(w http.ResponseWriter, r *http.Request) {
-	jsonResponse(w, http.StatusOK, StandardResponse{
-		Success: true,
-		Data:    DBListResponse{Databases: []DBInfoResponse{{Name: "default", IsActive: true}}},
-	})
+	mgr, err := getManager()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to init store manager: "+err.Error())
+		return
+	}
+
+	names, err := mgr.ListDatabases()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to list databases: "+err.Error())
+		return
+	}
+
+	active := activeDBName(r)
+	dbs := make([]DBInfoResponse, 0, len(names))
+	for _, name := range names {
+		dbs = append(dbs, DBInfoResponse{Name: name, IsActive: name == active, SchemaStatus: schemaStatus(name)})
+	}
+
+	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: DBListResponse{Databases: dbs}})
 }
 
 // HandleDBInfo - GET /api/v1/db/info
 func HandleDBInfo(w http.ResponseWriter, r *http.Request) {
+	name := activeDBName(r)
+
+	db, err := dbFromRequest(r)
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var docCount, chunkCount int
+	db.QueryRow(`SELECT COUNT(*) FROM documents`).Scan(&docCount)
+	db.QueryRow(`SELECT COUNT(*) FROM chunks`).Scan(&chunkCount)
+
 	jsonResponse(w, http.StatusOK, StandardResponse{
 		Success: true,
-		Data:    DBInfoResponse{Name: "default", DocCount: 100, IsActive: true},
+		Data: DBInfoResponse{
+			Name:             name,
+			DocCount:         docCount,
+			ChunkCount:       chunkCount,
+			IsActive:         true,
+			SupportedFormats: pipeline.Capabilities(),
+			SchemaStatus:     schemaStatus(name),
+		},
 	})
 }
 
+// schemaStatus reports dbName's store.MigrationPlan as a glanceable
+// config.MigrationStatus, for DBInfoResponse.SchemaStatus. Defaults to
+// "compatible" if the manager or stamped config can't be read, since those
+// failures are surfaced elsewhere (HandleDBInfo's own dbFromRequest call).
+func schemaStatus(dbName string) config.MigrationStatus {
+	mgr, err := getManager()
+	if err != nil {
+		return config.StatusCompatible
+	}
+	state, err := mgr.GetDBConfig(dbName)
+	if err != nil {
+		return config.StatusCompatible
+	}
+	return store.PlanMigration(state).Status()
+}
+
 // HandleDBDelete - DELETE /api/v1/db/{name}
 func HandleDBDelete(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
+
+	mgr, err := getManager()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to init store manager: "+err.Error())
+		return
+	}
+
+	if err := mgr.DeleteDatabase(name); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Message: "Deleted " + name})
 }
+
+// HandleDBMigrate - POST /api/v1/db/{name}/migrate
+// Diffs the database's stamped model config against config.CurrentDefaults
+// and, if a re-embed is required, streams progress as SSE while it runs
+// (same framing as HandleBatchEvents). Rechunk/RerunNER-only plans are
+// reported but not executed here; they still count as pending
+// (plan.Required()), so writes and searches stay refused for that database.
+func HandleDBMigrate(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	mgr, err := getManager()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to init store manager: "+err.Error())
+		return
+	}
+
+	db, plan, err := mgr.OpenDatabase(name)
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer db.Close()
+
+	if !plan.Required() {
+		jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Message: "Database already matches current model config"})
+		return
+	}
+
+	if !plan.ReembedAll {
+		// Rechunk/RerunNER-only: this handler has no step that executes
+		// these, so just report what's pending instead of streaming a no-op
+		// migration.
+		jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: plan})
+		return
+	}
+
+	if workerPools.embed == nil || workerPools.vector == nil {
+		errorResponse(w, http.StatusServiceUnavailable, "Embed/vector worker pools are not configured")
+		return
+	}
+
+	if !mgr.TryStartMigration(name) {
+		errorResponse(w, http.StatusConflict, "A migration is already running for "+name)
+		return
+	}
+	defer mgr.FinishMigration(name)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(p store.MigrationProgress) {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", p.Stage, data)
+		flusher.Flush()
+	}
+
+	dbDir := mgr.GetDBPath(name)
+	migrator := store.NewMigrator(db, dbDir, workerPools.embed, workerPools.vector, 32)
+	if err := migrator.Run(send); err != nil {
+		send(store.MigrationProgress{Stage: "error", Error: err.Error()})
+	}
+}
+
+// HandleDBMigrateStart - POST /api/v1/db/migrate
+// Like HandleDBMigrate, but targets the database resolved by DBContext for
+// this request (X-RAG-DB/?db=/JSON "db") and kicks the re-embed off in the
+// background instead of streaming it, so the caller can poll progress via
+// HandleDBMigrateStatus instead of holding a connection open.
+func HandleDBMigrateStart(w http.ResponseWriter, r *http.Request) {
+	name := activeDBName(r)
+
+	mgr, err := getManager()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to init store manager: "+err.Error())
+		return
+	}
+
+	db, plan, err := mgr.OpenDatabase(name)
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if !plan.Required() {
+		db.Close()
+		jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Message: "Database already matches current model config"})
+		return
+	}
+	if !plan.ReembedAll {
+		db.Close()
+		jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: plan})
+		return
+	}
+	if workerPools.embed == nil || workerPools.vector == nil {
+		db.Close()
+		errorResponse(w, http.StatusServiceUnavailable, "Embed/vector worker pools are not configured")
+		return
+	}
+
+	if !mgr.TryStartMigration(name) {
+		db.Close()
+		errorResponse(w, http.StatusConflict, "A migration is already running for "+name)
+		return
+	}
+
+	dbDir := mgr.GetDBPath(name)
+	migrator := store.NewMigrator(db, dbDir, workerPools.embed, workerPools.vector, 32)
+
+	go func() {
+		defer db.Close()
+		defer mgr.FinishMigration(name)
+		if err := migrator.Run(nil); err != nil {
+			log.Printf("[migrate %s] failed: %v", name, err)
+		}
+	}()
+
+	jsonResponse(w, http.StatusAccepted, StandardResponse{Success: true, Message: "Migration started for " + name})
+}
+
+// HandleDBMigrateStatus - GET /api/v1/db/migrate/status
+// Reports the resolved database's persisted migration progress, read
+// straight out of its config table so it reflects reality even if the
+// migration was started by a different request (or a prior process that
+// crashed and was restarted).
+func HandleDBMigrateStatus(w http.ResponseWriter, r *http.Request) {
+	mgr, err := getManager()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to init store manager: "+err.Error())
+		return
+	}
+
+	db, _, err := mgr.OpenDatabase(activeDBName(r))
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer db.Close()
+
+	status, err := store.ReadMigrationStatus(db)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to read migration status: "+err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: status})
+}
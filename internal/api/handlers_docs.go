@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -8,10 +9,25 @@ import (
 	"os"
 	"time"
 
+	"github.com/GonzoDMX/rag-anywhere/internal/api/sse"
 	"github.com/GonzoDMX/rag-anywhere/internal/ingest"
 	"github.com/GonzoDMX/rag-anywhere/internal/pipeline"
 )
 
+// batchJobs tracks progress/cancellation state for every in-flight
+// /docs/batch run. Shared across handlers in this file.
+var batchJobs = pipeline.NewJobManager()
+
+// batchJobRetention is how long a finished job's bookkeeping stays queryable
+// via HandleBatchStatus/HandleBatchEvents before it's reaped, so a client
+// polling right after "batch_done" still gets one last look.
+const batchJobRetention = 10 * time.Minute
+
+// batchEvents fans out {event, data} progress out to every SSE subscriber
+// of a given batch ID, so e.g. two browser tabs watching the same batch
+// each get their own copy. Topic is the batch ID.
+var batchEvents = sse.NewBroker()
+
 // ==========================================
 // DOCUMENT OPERATIONS
 // ==========================================
@@ -21,6 +37,10 @@ import (
 // HandleDocAdd - POST /api/v1/docs/add
 // Synchronous: Uploads -> Extracts Text -> Returns Result (or Error).
 func HandleDocAdd(w http.ResponseWriter, r *http.Request) {
+	if rejectIfMigrationPending(r, w) {
+		return
+	}
+
 	// 1. Parse Multipart (Max 32MB)
 	if err := r.ParseMultipartForm(32 << 20); err != nil {
 		errorResponse(w, http.StatusBadRequest, "File too large or invalid")
@@ -35,14 +55,9 @@ func HandleDocAdd(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// 3. Validation (Mime Type)
-	buffer := make([]byte, 512)
-	file.Read(buffer)
-	file.Seek(0, 0)
-	mime := http.DetectContentType(buffer)
-
-	if !ingest.IsSupported(header.Filename, buffer) {
-		errorResponse(w, http.StatusUnsupportedMediaType, "Unsupported file type: "+mime)
+	// 3. Validation (signature + extension)
+	if reject := ingest.Validate(header.Filename, file); reject != nil {
+		errorResponse(w, http.StatusUnsupportedMediaType, reject.Error())
 		return
 	}
 
@@ -61,12 +76,33 @@ func HandleDocAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 6. PIPELINE: Extract Text
-	text, err := pipeline.ExtractDocument(path)
+	// TODO: segments aren't persisted anywhere yet — there's no chunk+embed
+	// path wired into this handler at all (see the placeholder response
+	// below). Once one exists, CreateSubChunks/CreateRecursiveSubChunks
+	// should snap to segment boundaries (never split a heading from its
+	// paragraph, keep table cells whole) before InsertChunk is called, so
+	// section_path can be populated from segment.StyleHint instead of
+	// left empty.
+	text, extracted, segments, err := pipeline.ExtractDocumentWithSegments(path)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Extraction failed: "+err.Error())
 		return
 	}
 
+	// Merge structured fields the extractor pulled out (EXIF/ID3 for
+	// images/audio) into the user-supplied metadata, without clobbering
+	// anything the caller explicitly set.
+	if len(extracted) > 0 {
+		if meta == nil {
+			meta = make(map[string]interface{}, len(extracted))
+		}
+		for k, v := range extracted {
+			if _, exists := meta[k]; !exists {
+				meta[k] = v
+			}
+		}
+	}
+
 	// Placeholder Response
 	fakeDoc := DocResponse{
 		ID:         "doc_123",
@@ -80,13 +116,17 @@ func HandleDocAdd(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusCreated, StandardResponse{
 		Success: true,
 		Data:    fakeDoc,
-		Meta:    map[string]interface{}{"extracted_chars": len(text)},
+		Meta:    map[string]interface{}{"extracted_chars": len(text), "extracted_segments": len(segments)},
 	})
 }
 
 // HandleDocAddBatch - POST /api/v1/docs/batch
 // Asynchronous: Returns Batch ID immediately. Background worker handles Extraction.
 func HandleDocAddBatch(w http.ResponseWriter, r *http.Request) {
+	if rejectIfMigrationPending(r, w) {
+		return
+	}
+
 	// Max 100MB for batches
 	if err := r.ParseMultipartForm(100 << 20); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Request too large")
@@ -101,21 +141,17 @@ func HandleDocAddBatch(w http.ResponseWriter, r *http.Request) {
 
 	batchID := fmt.Sprintf("batch_%d", time.Now().Unix())
 	var validPaths []string
-	var rejectedFiles []string
+	var rejectedFiles []RejectedFile
 
 	for _, fileHeader := range files {
 		f, err := fileHeader.Open()
 		if err != nil {
-			rejectedFiles = append(rejectedFiles, fileHeader.Filename)
+			rejectedFiles = append(rejectedFiles, RejectedFile{Filename: fileHeader.Filename, Reason: "open_error", Detail: err.Error()})
 			continue
 		}
 
-		// Mime Check
-		buf := make([]byte, 512)
-		f.Read(buf)
-		f.Seek(0, 0)
-		if !ingest.IsSupported(fileHeader.Filename, buf) {
-			rejectedFiles = append(rejectedFiles, fileHeader.Filename+" (unsupported)")
+		if reject := ingest.Validate(fileHeader.Filename, f); reject != nil {
+			rejectedFiles = append(rejectedFiles, RejectedFile{Filename: fileHeader.Filename, Reason: string(reject.Reason), Detail: reject.Detail})
 			f.Close()
 			continue
 		}
@@ -125,7 +161,7 @@ func HandleDocAddBatch(w http.ResponseWriter, r *http.Request) {
 		f.Close()
 
 		if err != nil {
-			rejectedFiles = append(rejectedFiles, fileHeader.Filename+" (save error)")
+			rejectedFiles = append(rejectedFiles, RejectedFile{Filename: fileHeader.Filename, Reason: "save_error", Detail: err.Error()})
 		} else {
 			validPaths = append(validPaths, path)
 		}
@@ -137,18 +173,54 @@ func HandleDocAddBatch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Dispatch Background Worker
-	go func(bID string, paths []string) {
+	job := batchJobs.Create(context.Background(), batchID, len(validPaths))
+
+	go func(job *pipeline.Job, bID string, paths []string) {
+		defer job.Cancel() // guarantee the job's context is never leaked
 		log.Printf("[Batch %s] Processing %d files...", bID, len(paths))
+
+		status := "completed"
 		for _, p := range paths {
+			select {
+			case <-job.Ctx.Done():
+				status = "cancelled"
+				os.Remove(p)
+				continue
+			default:
+			}
+
+			job.MarkFileStarted(p)
+			batchEvents.Publish(bID, sse.Event{Name: "file_started", Data: map[string]string{"file": p}})
+
+			// TODO: publish "chunk_progress"/"embed_progress" events once
+			// this path actually sub-chunks and calls the embed worker pool
+			// (see chunkTextsFromFile/estimateTokens) instead of just
+			// extracting text; ipc.PythonService.ProcessStream is built for
+			// relaying the embedder's intermediate progress frames here.
 			_, err := pipeline.ExtractDocument(p)
 			if err != nil {
 				log.Printf("[Batch %s] Failed to extract %s: %v", bID, p, err)
 			} else {
 				// TODO: Send to Embedder
 			}
+			job.MarkFileDone(p, err)
+
+			snap := job.Snapshot()
+			evData := map[string]interface{}{"file": p, "processed": snap.Processed, "total": snap.Total, "pct": snap.Pct}
+			if err != nil {
+				evData["error"] = err.Error()
+			}
+			batchEvents.Publish(bID, sse.Event{Name: "file_done", Data: evData})
 			os.Remove(p)
 		}
-	}(batchID, validPaths)
+
+		if status == "completed" && job.Snapshot().Failed == len(paths) {
+			status = "failed"
+		}
+		job.Finish(status)
+		batchEvents.Publish(bID, sse.Event{Name: "batch_done", Data: map[string]interface{}{"status": status}})
+		time.AfterFunc(batchJobRetention, func() { batchJobs.Delete(bID) })
+	}(job, batchID, validPaths)
 
 	jsonResponse(w, http.StatusAccepted, StandardResponse{
 		Success: true,
@@ -196,16 +268,93 @@ func HandleDocQuery(w http.ResponseWriter, r *http.Request) {
 // HandleBatchStatus - GET /api/v1/docs/batch/{id}
 func HandleBatchStatus(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+
+	job, ok := batchJobs.Get(id)
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Unknown batch id: "+id)
+		return
+	}
+
+	snap := job.Snapshot()
 	jsonResponse(w, http.StatusOK, StandardResponse{
 		Success: true,
-		Data:    BatchStatusResponse{BatchID: id, Status: "processing", ProgressPct: 50.0},
+		Data: BatchStatusResponse{
+			BatchID:     id,
+			Status:      snap.Status,
+			TotalFiles:  snap.Total,
+			Processed:   snap.Processed,
+			Failed:      snap.Failed,
+			ProgressPct: snap.Pct,
+			CurrentFile: snap.CurrentFile,
+			Failures:    snap.Failures,
+		},
 	})
 }
 
 // HandleBatchEvents - GET /api/v1/docs/batch/{id}/stream
+// Streams progress for a running batch as Server-Sent Events via
+// batchEvents, so multiple subscribers (e.g. two browser tabs) watching the
+// same batch each get their own copy. Unblocks as soon as the client
+// disconnects or the batch finishes.
 func HandleBatchEvents(w http.ResponseWriter, r *http.Request) {
-	// SSE implementation will go here
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+	id := r.PathValue("id")
+
+	job, ok := batchJobs.Get(id)
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Unknown batch id: "+id)
+		return
+	}
+
+	ch, unsubscribe := batchEvents.Subscribe(id)
+	defer unsubscribe()
+
+	if snap := job.Snapshot(); snap.Status != "processing" {
+		// Batch already finished before this subscriber connected, so
+		// there's nothing left for batchEvents to fan out; report the
+		// terminal state directly instead of hanging until r's context ends.
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			errorResponse(w, http.StatusInternalServerError, "Streaming unsupported")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		data, _ := json.Marshal(map[string]interface{}{"status": snap.Status})
+		fmt.Fprintf(w, "event: batch_done\ndata: %s\n\n", data)
+		flusher.Flush()
+		return
+	}
+
+	// Relay ch until "batch_done" so Stream also returns when the batch finishes.
+	fwd := make(chan sse.Event)
+	go func() {
+		defer close(fwd)
+		for ev := range ch {
+			select {
+			case fwd <- ev:
+			case <-r.Context().Done():
+				return
+			}
+			if ev.Name == "batch_done" {
+				return
+			}
+		}
+	}()
+	if err := sse.Stream(w, r, fwd, 15*time.Second); err != nil {
+		log.Printf("[Batch %s] events stream ended: %v", id, err)
+	}
+}
+
+// HandleBatchCancel - POST /api/v1/docs/batch/{id}/cancel
+func HandleBatchCancel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	job, ok := batchJobs.Get(id)
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Unknown batch id: "+id)
+		return
+	}
+
+	job.Cancel()
+	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Message: "Cancellation requested for " + id})
 }
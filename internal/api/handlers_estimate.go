@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/config"
+	"github.com/GonzoDMX/rag-anywhere/internal/ingest"
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+	"github.com/GonzoDMX/rag-anywhere/internal/pipeline"
+)
+
+var errEmbedWorkerUnavailable = errors.New("embed worker pool is not configured")
+
+// ==========================================
+// TOKEN BUDGET ESTIMATION
+// ==========================================
+
+// These handlers let a caller find out how many tokens a document (or set
+// of documents) will cost to embed before committing to /docs/add or
+// /docs/batch, using the embed worker's already-declared count_tokens
+// command.
+
+// countTokensBatchSize is how many chunk texts we send to the embed worker
+// per count_tokens call.
+const countTokensBatchSize = 32
+
+// stampedContextLength returns r's active database's stamped
+// embed_context_length, falling back to config.CurrentDefaults if the
+// database hasn't been created yet or wasn't stamped.
+func stampedContextLength(r *http.Request) int {
+	mgr, err := getManager()
+	if err == nil {
+		if state, err := mgr.GetDBConfig(activeDBName(r)); err == nil && state.EmbedContextLength > 0 {
+			return state.EmbedContextLength
+		}
+	}
+	return config.CurrentDefaults.EmbeddingModel.ContextLength
+}
+
+// chunkTextsFromFile validates, extracts, and sub-chunks an uploaded file,
+// returning the chunk texts that would be sent to the embedder.
+func chunkTextsFromFile(file multipart.File, filename string, maxTokens int) ([]string, error) {
+	if reject := ingest.Validate(filename, file); reject != nil {
+		return nil, reject
+	}
+
+	path, err := saveFileToStaging(file, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	text, err := pipeline.ExtractDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overlap := config.CurrentDefaults.Processing.ChunkOverlap
+	subChunks := pipeline.CreateSubChunks(text, pipeline.RegexTokenCounter{}, nil, maxTokens, overlap)
+
+	texts := make([]string, len(subChunks))
+	for i, sc := range subChunks {
+		texts[i] = sc.Text
+	}
+	return texts, nil
+}
+
+// estimateTokens runs count_tokens over texts in batches and reports the
+// aggregate cost, flagging any chunk that exceeds maxTokens. ctx is the
+// triggering request's context, so a client disconnect aborts the
+// in-flight worker call instead of running to completion unattended.
+func estimateTokens(ctx context.Context, texts []string, maxTokens int) (DocEstimateResponse, error) {
+	resp := DocEstimateResponse{ChunkCount: len(texts)}
+
+	if workerPools.embed == nil {
+		return resp, errEmbedWorkerUnavailable
+	}
+
+	for start := 0; start < len(texts); start += countTokensBatchSize {
+		end := start + countTokensBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		var workerResp models.WorkerEmbedResponse
+		req := models.WorkerEmbedRequest{Command: "count_tokens", Texts: texts[start:end]}
+		if err := workerPools.embed.Process(ctx, req, &workerResp); err != nil {
+			return resp, err
+		}
+		if workerResp.Error != "" {
+			return resp, errors.New(workerResp.Error)
+		}
+
+		for i, n := range workerResp.TokenCounts {
+			idx := start + i
+			resp.TotalTokens += n
+			if n > maxTokens {
+				resp.OversizeChunks = append(resp.OversizeChunks, OversizeChunk{Index: idx, Len: n})
+			}
+		}
+	}
+
+	resp.EstimatedEmbeddingCalls = (resp.ChunkCount + countTokensBatchSize - 1) / countTokensBatchSize
+	return resp, nil
+}
+
+// HandleDocEstimate - POST /api/v1/docs/estimate
+// Accepts either a multipart "file" field or a JSON body of raw strings
+// ({"texts": [...]}) and reports the token cost of embedding it.
+func HandleDocEstimate(w http.ResponseWriter, r *http.Request) {
+	maxTokens := stampedContextLength(r)
+	var texts []string
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			errorResponse(w, http.StatusBadRequest, "File too large or invalid")
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "Missing 'file' field")
+			return
+		}
+		defer file.Close()
+
+		texts, err = chunkTextsFromFile(file, header.Filename, maxTokens)
+		if err != nil {
+			var reject *ingest.IngestReject
+			if errors.As(err, &reject) {
+				errorResponse(w, http.StatusUnsupportedMediaType, reject.Error())
+			} else {
+				errorResponse(w, http.StatusInternalServerError, "Extraction failed: "+err.Error())
+			}
+			return
+		}
+	} else {
+		var req DocEstimateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		texts = req.Texts
+	}
+
+	if len(texts) == 0 {
+		errorResponse(w, http.StatusBadRequest, "No text to estimate")
+		return
+	}
+
+	resp, err := estimateTokens(r.Context(), texts, maxTokens)
+	if err != nil {
+		if err == errEmbedWorkerUnavailable {
+			errorResponse(w, http.StatusServiceUnavailable, err.Error())
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Token counting failed: "+err.Error())
+		}
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: resp})
+}
+
+// HandleDocBatchEstimate - POST /api/v1/docs/batch/estimate
+// Walks a multipart set of files and returns the aggregate token cost
+// across all of them, so a caller can budget before calling /docs/batch.
+func HandleDocBatchEstimate(w http.ResponseWriter, r *http.Request) {
+	maxTokens := stampedContextLength(r)
+
+	if err := r.ParseMultipartForm(100 << 20); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Request too large")
+		return
+	}
+
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		errorResponse(w, http.StatusBadRequest, "No files provided")
+		return
+	}
+
+	var allTexts []string
+	var skipped []string
+
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			skipped = append(skipped, fh.Filename+" (open error)")
+			continue
+		}
+		texts, err := chunkTextsFromFile(f, fh.Filename, maxTokens)
+		f.Close()
+		if err != nil {
+			skipped = append(skipped, fh.Filename+": "+err.Error())
+			continue
+		}
+		allTexts = append(allTexts, texts...)
+	}
+
+	if len(allTexts) == 0 {
+		errorResponse(w, http.StatusBadRequest, "No valid files to estimate")
+		return
+	}
+
+	resp, err := estimateTokens(r.Context(), allTexts, maxTokens)
+	if err != nil {
+		if err == errEmbedWorkerUnavailable {
+			errorResponse(w, http.StatusServiceUnavailable, err.Error())
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Token counting failed: "+err.Error())
+		}
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    resp,
+		Meta:    map[string]interface{}{"skipped_files": skipped},
+	})
+}
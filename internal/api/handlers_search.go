@@ -2,7 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/api/sse"
+	"github.com/GonzoDMX/rag-anywhere/internal/store"
 )
 
 // Generic helper to parse search requests
@@ -18,18 +25,55 @@ func parseSearchReq(r *http.Request, dest interface{}) error {
 // code search, question answering, fact checking, keyword search, hybrid search, and graph-enhanced search.
 
 // HandleSearchSemantic - POST /api/v1/search/semantic
+// If the caller sends "Accept: text/event-stream", results are streamed as
+// individual "result" SSE events (so the frontend can render hits as they
+// arrive from the reranker) followed by a terminal "done" event, instead of
+// a single StandardResponse.
 func HandleSearchSemantic(w http.ResponseWriter, r *http.Request) {
+	if rejectIfDBUnavailable(r, w, false) {
+		return
+	}
 	var req SearchSemanticReq
 	if err := parseSearchReq(r, &req); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
-	// Call Python Embedder -> Faiss -> SQLite
-	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: SearchResponse{Results: []SearchResult{}}})
+
+	// TODO: replace with a real Python embedder -> Faiss -> SQLite lookup;
+	// until then both the streamed and non-streamed paths below return no
+	// hits.
+	results := []SearchResult{}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		streamSearchResults(w, r, results)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: SearchResponse{Results: results}})
+}
+
+// streamSearchResults sends one SSE "result" event per hit in results,
+// followed by a terminal "done" event, using sse.Stream's framing. results
+// are all computed before this is called, so every event is already
+// queued and the stream is written in one pass.
+func streamSearchResults(w http.ResponseWriter, r *http.Request, results []SearchResult) {
+	ch := make(chan sse.Event, len(results)+1)
+	for _, res := range results {
+		ch <- sse.Event{Name: "result", Data: res}
+	}
+	ch <- sse.Event{Name: "done", Data: struct{}{}}
+	close(ch)
+
+	if err := sse.Stream(w, r, ch, 15*time.Second); err != nil {
+		log.Printf("search/semantic stream ended: %v", err)
+	}
 }
 
 // HandleSearchCode - POST /api/v1/search/code
 func HandleSearchCode(w http.ResponseWriter, r *http.Request) {
+	if rejectIfDBUnavailable(r, w, false) {
+		return
+	}
 	var req SearchCodeReq
 	if err := parseSearchReq(r, &req); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Invalid JSON")
@@ -40,6 +84,9 @@ func HandleSearchCode(w http.ResponseWriter, r *http.Request) {
 
 // HandleSearchQuestion - POST /api/v1/search/question
 func HandleSearchQuestion(w http.ResponseWriter, r *http.Request) {
+	if rejectIfDBUnavailable(r, w, false) {
+		return
+	}
 	var req SearchSemanticReq
 	parseSearchReq(r, &req)
 	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: SearchResponse{}})
@@ -47,6 +94,9 @@ func HandleSearchQuestion(w http.ResponseWriter, r *http.Request) {
 
 // HandleSearchFact - POST /api/v1/search/fact
 func HandleSearchFact(w http.ResponseWriter, r *http.Request) {
+	if rejectIfDBUnavailable(r, w, false) {
+		return
+	}
 	var req SearchFactReq
 	parseSearchReq(r, &req)
 	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: SearchResponse{}})
@@ -54,6 +104,9 @@ func HandleSearchFact(w http.ResponseWriter, r *http.Request) {
 
 // HandleSearchKeyword - POST /api/v1/search/keyword
 func HandleSearchKeyword(w http.ResponseWriter, r *http.Request) {
+	if rejectIfDBUnavailable(r, w, true) {
+		return
+	}
 	var req SearchKeywordReq
 	parseSearchReq(r, &req)
 	// Call SQLite FTS5
@@ -61,13 +114,128 @@ func HandleSearchKeyword(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleSearchHybrid - POST /api/v1/search/hybrid
+// Combines the semantic and keyword retrievers per req.FusionMode
+// (FusionWeighted/FusionRRF/FusionRerank, default FusionWeighted).
 func HandleSearchHybrid(w http.ResponseWriter, r *http.Request) {
+	if rejectIfDBUnavailable(r, w, false) {
+		return
+	}
+	start := time.Now()
+
 	var req SearchHybridReq
-	parseSearchReq(r, &req)
-	jsonResponse(w, http.StatusOK, StandardResponse{Success: true, Data: SearchResponse{}})
+	if err := parseSearchReq(r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if req.TopK <= 0 {
+		req.TopK = 10
+	}
+	if req.FusionMode == "" {
+		req.FusionMode = FusionWeighted
+	}
+
+	// TODO: replace with real retriever calls once HandleSearchSemantic
+	// (Python embedder -> Faiss -> SQLite) and HandleSearchKeyword (SQLite
+	// FTS5) are implemented; fusion below operates on whatever ranked
+	// result lists they return.
+	var semantic, keyword []SearchResult
+
+	var results []SearchResult
+	switch req.FusionMode {
+	case FusionRRF:
+		results = fuseRRF(semantic, keyword, req.TopK)
+	case FusionRerank:
+		var err error
+		results, err = fuseRerank(r.Context(), req.Query, semantic, keyword, req.TopK)
+		if err != nil {
+			errorResponse(w, http.StatusServiceUnavailable, "Rerank failed: "+err.Error())
+			return
+		}
+	case FusionWeighted:
+		results = fuseWeighted(semantic, keyword, req.Alpha, req.TopK)
+	default:
+		errorResponse(w, http.StatusBadRequest, "Unknown fusion_mode: "+req.FusionMode)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data: SearchResponse{
+			Results: results,
+			Total:   len(results),
+			Took:    time.Since(start).String(),
+		},
+	})
 }
 
 // HandleSearchKG - POST /api/v1/search/kg
+// Extracts seed entities from the query, BFS-walks the entity co-occurrence
+// graph out to Hops steps, and scores candidate chunks by the accumulated
+// edge weight of every entity they contain.
 func HandleSearchKG(w http.ResponseWriter, r *http.Request) {
-	errorResponse(w, http.StatusNotImplemented, "Graph search not yet implemented")
+	if rejectIfDBUnavailable(r, w, true) {
+		return
+	}
+	start := time.Now()
+
+	var req SearchKGReq
+	if err := parseSearchReq(r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if req.Hops <= 0 {
+		req.Hops = 2
+	}
+	if req.TopK <= 0 {
+		req.TopK = 10
+	}
+
+	db, err := dbFromRequest(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to open database: "+err.Error())
+		return
+	}
+
+	// TODO: replace the token-match fallback with the NER worker once
+	// worker pools are wired into the server (extract seed entities from
+	// req.Query using req.Labels, like ingest does for document chunks).
+	tokens := strings.Fields(req.Query)
+	seedIDs, err := store.EntitiesByText(db, tokens, req.Labels)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Seed entity lookup failed: "+err.Error())
+		return
+	}
+
+	const fanout = 10 // bounded fan-out per hop to avoid hub explosion
+	matches, err := store.TraverseEntityGraph(db, seedIDs, req.Hops, fanout)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Graph traversal failed: "+err.Error())
+		return
+	}
+
+	hits, err := store.ScoreChunksByEntityMatches(db, matches, req.TopK)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Chunk scoring failed: "+err.Error())
+		return
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, h := range hits {
+		results = append(results, SearchResult{
+			DocID:     fmt.Sprintf("%d", h.DocID),
+			ChunkID:   int(h.ChunkID),
+			Content:   h.Content,
+			Score:     float32(h.Score),
+			GraphPath: h.GraphPath,
+		})
+	}
+
+	jsonResponse(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data: SearchResponse{
+			Results: results,
+			Total:   len(results),
+			Took:    time.Since(start).String(),
+		},
+	})
 }
@@ -3,6 +3,8 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
 )
 
 // ==========================================
@@ -18,13 +20,25 @@ func HandleHealth(w http.ResponseWriter, r *http.Request) {
 }
 
 func HandleStatus(w http.ResponseWriter, r *http.Request) {
+	workers := make(map[string][]ipc.WorkerStat)
+	if pool, ok := workerPools.embed.(*ipc.WorkerPool); ok {
+		workers["embed"] = pool.PoolStats()
+	}
+	if pool, ok := workerPools.vector.(*ipc.WorkerPool); ok {
+		workers["vector"] = pool.PoolStats()
+	}
+	if len(workers) == 0 {
+		workers = nil
+	}
+
 	jsonResponse(w, http.StatusOK, StandardResponse{
 		Success: true,
 		Data: StatusResponse{
 			Status:   "healthy",
 			Uptime:   "10m",
-			ActiveDB: "default",
+			ActiveDB: activeDBName(r),
 			Version:  "0.1.0",
+			Workers:  workers,
 		},
 	})
 }
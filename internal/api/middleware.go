@@ -0,0 +1,69 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/store"
+)
+
+// resolveDBName determines which database r targets, checking (in order)
+// the X-RAG-DB header, the ?db= query param, and a JSON "db" field on the
+// request body. explicit reports whether the caller actually named a
+// database, as opposed to falling back to defaultDBName.
+func resolveDBName(r *http.Request) (name string, explicit bool) {
+	if h := r.Header.Get("X-RAG-DB"); h != "" {
+		return h, true
+	}
+	if q := r.URL.Query().Get("db"); q != "" {
+		return q, true
+	}
+	if r.Body != nil && (r.Method == http.MethodPost || r.Method == http.MethodPut) {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			r.Body = io.NopCloser(bytes.NewReader(nil))
+			return defaultDBName, false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var peek struct {
+			DB string `json:"db"`
+		}
+		if json.Unmarshal(body, &peek) == nil && peek.DB != "" {
+			return peek.DB, true
+		}
+	}
+	return defaultDBName, false
+}
+
+// DBContext resolves the target database for every request and attaches a
+// *store.DBHandle to its context via store.WithDB, so handlers can fetch it
+// with store.FromContext (or the dbFromRequest/activeDBName helpers in
+// common.go) instead of opening their own connection. An explicitly
+// requested but unknown database name is rejected with 400; a silently
+// defaulted name that doesn't exist yet is left unattached so first-run
+// handlers (e.g. HandleDBCreate) still work.
+func DBContext(mgr *store.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, explicit := resolveDBName(r)
+
+			db, err := mgr.OpenCached(name)
+			if err != nil {
+				if explicit {
+					errorResponse(w, http.StatusBadRequest, "Unknown database: "+name)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			handle := mgr.NewDBHandle(name, db)
+			r = r.WithContext(store.WithDB(r.Context(), handle))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+// Fusion modes for SearchHybridReq.FusionMode.
+const (
+	FusionWeighted = "weighted" // blend raw scores by Alpha (default)
+	FusionRRF      = "rrf"      // Reciprocal Rank Fusion over both rank lists
+	FusionRerank   = "rerank"   // cross-encoder re-score over the union of both lists
+)
+
+// rrfK is the canonical Reciprocal Rank Fusion constant: score(c) = sum
+// 1/(k + rank_i(c)) across retriever result lists.
+const rrfK = 60
+
+// rerankCandidates bounds how many of each retriever's top results feed
+// FusionRerank — the cross-encoder is far more expensive per-item than
+// either retriever, so only the most promising candidates are re-scored.
+const rerankCandidates = 50
+
+// fuseWeighted blends semantic and keyword scores by alpha (weight on the
+// semantic side). A chunk absent from one list contributes 0 for that
+// side rather than being dropped.
+func fuseWeighted(semantic, keyword []SearchResult, alpha float32, topK int) []SearchResult {
+	byChunk := make(map[int]*SearchResult)
+	semScore := make(map[int]float32)
+	keyScore := make(map[int]float32)
+
+	for i, r := range semantic {
+		res := r
+		byChunk[r.ChunkID] = &res
+		semScore[r.ChunkID] = r.Score
+		byChunk[r.ChunkID].SemanticRank = i + 1
+	}
+	for i, r := range keyword {
+		if existing, ok := byChunk[r.ChunkID]; ok {
+			existing.KeywordRank = i + 1
+			if existing.Content == "" {
+				existing.Content = r.Content
+			}
+		} else {
+			res := r
+			res.KeywordRank = i + 1
+			byChunk[r.ChunkID] = &res
+		}
+		keyScore[r.ChunkID] = r.Score
+	}
+
+	out := make([]SearchResult, 0, len(byChunk))
+	for id, res := range byChunk {
+		res.Score = alpha*semScore[id] + (1-alpha)*keyScore[id]
+		out = append(out, *res)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return truncateResults(out, topK)
+}
+
+// fuseRRF merges ranked semantic and keyword result lists by Reciprocal
+// Rank Fusion and returns the top topK by fused score. Rank is 1-based
+// position within each input list; a chunk absent from a list contributes
+// 0 for that list instead of being penalized further.
+func fuseRRF(semantic, keyword []SearchResult, topK int) []SearchResult {
+	byChunk := make(map[int]*SearchResult)
+
+	add := func(list []SearchResult, setRank func(res *SearchResult, rank int)) {
+		for i, r := range list {
+			rank := i + 1
+			res, ok := byChunk[r.ChunkID]
+			if !ok {
+				copied := r
+				copied.Score = 0 // replaced entirely by the fused RRF score below
+				res = &copied
+				byChunk[r.ChunkID] = res
+			}
+			setRank(res, rank)
+			res.Score += 1.0 / float32(rrfK+rank)
+		}
+	}
+
+	add(semantic, func(res *SearchResult, rank int) { res.SemanticRank = rank })
+	add(keyword, func(res *SearchResult, rank int) { res.KeywordRank = rank })
+
+	out := make([]SearchResult, 0, len(byChunk))
+	for _, res := range byChunk {
+		out = append(out, *res)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return truncateResults(out, topK)
+}
+
+// fuseRerank takes the union of the top rerankCandidates from each
+// retriever, scores every (query, chunk content) pair with the rerank
+// worker pool, and returns the top topK by that cross-encoder score.
+func fuseRerank(ctx context.Context, query string, semantic, keyword []SearchResult, topK int) ([]SearchResult, error) {
+	if workerPools.rerank == nil {
+		return nil, fmt.Errorf("rerank worker pool is not configured")
+	}
+
+	byChunk := make(map[int]*SearchResult)
+	union := func(list []SearchResult, setRank func(res *SearchResult, rank int)) {
+		for i, r := range list {
+			if i >= rerankCandidates {
+				break
+			}
+			res, ok := byChunk[r.ChunkID]
+			if !ok {
+				copied := r
+				res = &copied
+				byChunk[r.ChunkID] = res
+			}
+			setRank(res, i+1)
+		}
+	}
+	union(semantic, func(res *SearchResult, rank int) { res.SemanticRank = rank })
+	union(keyword, func(res *SearchResult, rank int) { res.KeywordRank = rank })
+
+	candidates := make([]*SearchResult, 0, len(byChunk))
+	texts := make([]string, 0, len(byChunk))
+	for _, res := range byChunk {
+		candidates = append(candidates, res)
+		texts = append(texts, res.Content)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var resp models.WorkerRerankResponse
+	req := models.WorkerRerankRequest{Query: query, Texts: texts}
+	if err := workerPools.rerank.Process(ctx, req, &resp); err != nil {
+		return nil, fmt.Errorf("rerank worker: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("rerank worker: %s", resp.Error)
+	}
+	if len(resp.Scores) != len(candidates) {
+		return nil, fmt.Errorf("rerank worker returned %d scores for %d candidates", len(resp.Scores), len(candidates))
+	}
+
+	out := make([]SearchResult, len(candidates))
+	for i, res := range candidates {
+		res.RerankScore = resp.Scores[i]
+		res.Score = resp.Scores[i]
+		out[i] = *res
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return truncateResults(out, topK), nil
+}
+
+// truncateResults caps results to topK (0 or negative means "no limit").
+func truncateResults(results []SearchResult, topK int) []SearchResult {
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+func TestFuseWeightedBlendsScores(t *testing.T) {
+	semantic := []SearchResult{
+		{ChunkID: 1, Content: "sem one", Score: 0.9},
+		{ChunkID: 2, Content: "sem two", Score: 0.5},
+	}
+	keyword := []SearchResult{
+		{ChunkID: 2, Content: "key two", Score: 1.0},
+		{ChunkID: 3, Content: "key three", Score: 0.8},
+	}
+
+	out := fuseWeighted(semantic, keyword, 0.5, 10)
+	if len(out) != 3 {
+		t.Fatalf("got %d results, want 3 (union of both lists)", len(out))
+	}
+
+	byID := make(map[int]SearchResult)
+	for _, r := range out {
+		byID[r.ChunkID] = r
+	}
+
+	// chunk 1: only semantic, 0.5*0.9 + 0.5*0 = 0.45
+	if got := byID[1].Score; got != 0.45 {
+		t.Errorf("chunk 1 score = %v, want 0.45", got)
+	}
+	// chunk 2: in both, 0.5*0.5 + 0.5*1.0 = 0.75
+	if got := byID[2].Score; got != 0.75 {
+		t.Errorf("chunk 2 score = %v, want 0.75", got)
+	}
+	// chunk 3: only keyword, 0.5*0 + 0.5*0.8 = 0.4
+	if got := byID[3].Score; got != 0.4 {
+		t.Errorf("chunk 3 score = %v, want 0.4", got)
+	}
+
+	if byID[2].SemanticRank != 2 || byID[2].KeywordRank != 1 {
+		t.Errorf("chunk 2 ranks = (sem %d, key %d), want (2, 1)", byID[2].SemanticRank, byID[2].KeywordRank)
+	}
+
+	// Results must come back sorted by fused score, descending.
+	for i := 1; i < len(out); i++ {
+		if out[i-1].Score < out[i].Score {
+			t.Errorf("results not sorted descending: %v before %v", out[i-1].Score, out[i].Score)
+		}
+	}
+}
+
+func TestFuseWeightedFillsContentFromKeywordWhenSemanticEmpty(t *testing.T) {
+	// A chunk that only arrived via the semantic list with no Content set
+	// (shouldn't happen in practice, but the fallback exists) picks up the
+	// keyword list's Content instead of leaving it blank.
+	semantic := []SearchResult{{ChunkID: 1, Content: "", Score: 0.9}}
+	keyword := []SearchResult{{ChunkID: 1, Content: "from keyword", Score: 0.2}}
+
+	out := fuseWeighted(semantic, keyword, 0.5, 10)
+	if len(out) != 1 {
+		t.Fatalf("got %d results, want 1", len(out))
+	}
+	if out[0].Content != "from keyword" {
+		t.Errorf("Content = %q, want %q", out[0].Content, "from keyword")
+	}
+}
+
+func TestFuseRRFScoresByReciprocalRank(t *testing.T) {
+	semantic := []SearchResult{
+		{ChunkID: 1, Content: "a"},
+		{ChunkID: 2, Content: "b"},
+	}
+	keyword := []SearchResult{
+		{ChunkID: 2, Content: "b"},
+		{ChunkID: 1, Content: "a"},
+	}
+
+	out := fuseRRF(semantic, keyword, 10)
+	if len(out) != 2 {
+		t.Fatalf("got %d results, want 2", len(out))
+	}
+
+	want := map[int]float32{
+		1: 1.0/float32(rrfK+1) + 1.0/float32(rrfK+2), // semantic rank 1, keyword rank 2
+		2: 1.0/float32(rrfK+2) + 1.0/float32(rrfK+1), // semantic rank 2, keyword rank 1
+	}
+	for _, r := range out {
+		if r.Score != want[r.ChunkID] {
+			t.Errorf("chunk %d score = %v, want %v", r.ChunkID, r.Score, want[r.ChunkID])
+		}
+	}
+	// Both chunks hit the same two ranks in opposite order, so they tie.
+	if out[0].Score != out[1].Score {
+		t.Errorf("expected a tie between the two chunks, got %v and %v", out[0].Score, out[1].Score)
+	}
+}
+
+func TestFuseRRFChunkAbsentFromOneList(t *testing.T) {
+	semantic := []SearchResult{{ChunkID: 1, Content: "only semantic"}}
+	keyword := []SearchResult{{ChunkID: 2, Content: "only keyword"}}
+
+	out := fuseRRF(semantic, keyword, 10)
+	if len(out) != 2 {
+		t.Fatalf("got %d results, want 2", len(out))
+	}
+	for _, r := range out {
+		want := 1.0 / float32(rrfK+1)
+		if r.Score != want {
+			t.Errorf("chunk %d score = %v, want %v (single-list rank-1 contribution)", r.ChunkID, r.Score, want)
+		}
+	}
+}
+
+func TestFuseRRFRespectsTopK(t *testing.T) {
+	semantic := []SearchResult{
+		{ChunkID: 1}, {ChunkID: 2}, {ChunkID: 3},
+	}
+	out := fuseRRF(semantic, nil, 2)
+	if len(out) != 2 {
+		t.Fatalf("got %d results, want 2 (topK)", len(out))
+	}
+}
+
+func TestTruncateResultsCaps(t *testing.T) {
+	results := []SearchResult{{ChunkID: 1}, {ChunkID: 2}, {ChunkID: 3}}
+
+	if got := truncateResults(results, 2); len(got) != 2 {
+		t.Errorf("truncateResults(_, 2) returned %d results, want 2", len(got))
+	}
+	if got := truncateResults(results, 0); len(got) != 3 {
+		t.Errorf("truncateResults(_, 0) returned %d results, want 3 (no limit)", len(got))
+	}
+	if got := truncateResults(results, -1); len(got) != 3 {
+		t.Errorf("truncateResults(_, -1) returned %d results, want 3 (no limit)", len(got))
+	}
+	if got := truncateResults(results, 10); len(got) != 3 {
+		t.Errorf("truncateResults(_, 10) returned %d results, want 3 (fewer than topK)", len(got))
+	}
+}
+
+func TestFuseRerankNoPoolConfigured(t *testing.T) {
+	old := workerPools.rerank
+	workerPools.rerank = nil
+	defer func() { workerPools.rerank = old }()
+
+	_, err := fuseRerank(context.Background(), "query", nil, nil, 10)
+	if err == nil {
+		t.Error("fuseRerank with no rerank pool configured returned no error")
+	}
+}
+
+func TestFuseRerankNoCandidates(t *testing.T) {
+	old := workerPools.rerank
+	workerPools.rerank = fakeRerankClient{}
+	defer func() { workerPools.rerank = old }()
+
+	out, err := fuseRerank(context.Background(), "query", nil, nil, 10)
+	if err != nil {
+		t.Fatalf("fuseRerank with no candidates returned error: %v", err)
+	}
+	if out != nil {
+		t.Errorf("fuseRerank with no candidates = %v, want nil", out)
+	}
+}
+
+func TestFuseRerankScoresAndSorts(t *testing.T) {
+	old := workerPools.rerank
+	// Score candidates in the reverse of the order they're handed to the
+	// worker, so a correct implementation must reorder its output rather
+	// than trust the candidates' original order.
+	workerPools.rerank = fakeRerankClient{scoreFn: func(texts []string) []float32 {
+		scores := make([]float32, len(texts))
+		for i := range texts {
+			scores[i] = float32(len(texts) - i)
+		}
+		return scores
+	}}
+	defer func() { workerPools.rerank = old }()
+
+	semantic := []SearchResult{
+		{ChunkID: 1, Content: "one"},
+		{ChunkID: 2, Content: "two"},
+	}
+	keyword := []SearchResult{
+		{ChunkID: 3, Content: "three"},
+	}
+
+	out, err := fuseRerank(context.Background(), "query", semantic, keyword, 10)
+	if err != nil {
+		t.Fatalf("fuseRerank returned error: %v", err)
+	}
+	if len(out) != 3 {
+		t.Fatalf("got %d results, want 3 (union of semantic+keyword)", len(out))
+	}
+	for i := 1; i < len(out); i++ {
+		if out[i-1].Score < out[i].Score {
+			t.Errorf("results not sorted descending by rerank score: %v before %v", out[i-1].Score, out[i].Score)
+		}
+		if out[i-1].RerankScore != out[i-1].Score {
+			t.Errorf("RerankScore = %v, want it to match Score %v", out[i-1].RerankScore, out[i-1].Score)
+		}
+	}
+}
+
+func TestFuseRerankCapsAtRerankCandidates(t *testing.T) {
+	old := workerPools.rerank
+	var gotTexts int
+	workerPools.rerank = fakeRerankClient{scoreFn: func(texts []string) []float32 {
+		gotTexts = len(texts)
+		scores := make([]float32, len(texts))
+		return scores
+	}}
+	defer func() { workerPools.rerank = old }()
+
+	semantic := make([]SearchResult, rerankCandidates+20)
+	for i := range semantic {
+		semantic[i] = SearchResult{ChunkID: i, Content: fmt.Sprintf("chunk %d", i)}
+	}
+
+	if _, err := fuseRerank(context.Background(), "query", semantic, nil, 10); err != nil {
+		t.Fatalf("fuseRerank returned error: %v", err)
+	}
+	if gotTexts != rerankCandidates {
+		t.Errorf("worker was sent %d candidates, want the rerankCandidates cap of %d", gotTexts, rerankCandidates)
+	}
+}
+
+func TestFuseRerankPropagatesWorkerError(t *testing.T) {
+	old := workerPools.rerank
+	workerPools.rerank = fakeRerankClient{err: fmt.Errorf("sidecar down")}
+	defer func() { workerPools.rerank = old }()
+
+	if _, err := fuseRerank(context.Background(), "query", []SearchResult{{ChunkID: 1, Content: "a"}}, nil, 10); err == nil {
+		t.Error("fuseRerank returned no error when the worker pool's Process call failed")
+	}
+}
+
+func TestFuseRerankPropagatesResponseError(t *testing.T) {
+	old := workerPools.rerank
+	workerPools.rerank = fakeRerankClient{respErr: "model failed to load"}
+	defer func() { workerPools.rerank = old }()
+
+	if _, err := fuseRerank(context.Background(), "query", []SearchResult{{ChunkID: 1, Content: "a"}}, nil, 10); err == nil {
+		t.Error("fuseRerank returned no error when the worker's response carried an Error field")
+	}
+}
+
+func TestFuseRerankRejectsScoreCountMismatch(t *testing.T) {
+	old := workerPools.rerank
+	workerPools.rerank = fakeRerankClient{scoreFn: func(texts []string) []float32 {
+		return []float32{1.0} // always one score, regardless of candidate count
+	}}
+	defer func() { workerPools.rerank = old }()
+
+	semantic := []SearchResult{{ChunkID: 1, Content: "a"}, {ChunkID: 2, Content: "b"}}
+	if _, err := fuseRerank(context.Background(), "query", semantic, nil, 10); err == nil {
+		t.Error("fuseRerank returned no error when the worker returned the wrong number of scores")
+	}
+}
+
+// fakeRerankClient is a test double for ipc.Client that answers rerank
+// requests without spawning a Python worker.
+type fakeRerankClient struct {
+	scoreFn func(texts []string) []float32
+	err     error
+	respErr string
+}
+
+func (f fakeRerankClient) Process(ctx context.Context, req interface{}, resp interface{}) error {
+	if f.err != nil {
+		return f.err
+	}
+	r, ok := req.(models.WorkerRerankRequest)
+	if !ok {
+		return fmt.Errorf("fakeRerankClient: unexpected request type %T", req)
+	}
+	out, ok := resp.(*models.WorkerRerankResponse)
+	if !ok {
+		return fmt.Errorf("fakeRerankClient: unexpected response type %T", resp)
+	}
+	if f.respErr != "" {
+		out.Error = f.respErr
+		return nil
+	}
+	if f.scoreFn != nil {
+		out.Scores = f.scoreFn(r.Texts)
+		return nil
+	}
+	out.Scores = make([]float32, len(r.Texts))
+	return nil
+}
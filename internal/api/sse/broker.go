@@ -0,0 +1,120 @@
+// Package sse provides a small reusable Server-Sent Events fan-out broker,
+// shared by any handler that needs to push progress to one or more
+// subscribers over a long-lived HTTP response (batch ingest, streaming
+// search, future migration progress, etc).
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event is one server-sent event: a named stage plus its JSON-encodable
+// payload.
+type Event struct {
+	Name string
+	Data interface{}
+}
+
+// Broker fans out events published under a topic (e.g. a batch ID) to
+// every subscriber currently listening on it. Unlike a single shared
+// channel, N subscribers — say two browser tabs watching the same batch —
+// each get their own independent copy.
+type Broker struct {
+	mu     sync.Mutex
+	topics map[string]map[chan Event]struct{}
+}
+
+// NewBroker creates an empty broker.
+func NewBroker() *Broker {
+	return &Broker{topics: make(map[string]map[chan Event]struct{})}
+}
+
+// Subscribe registers a new subscriber for topic and returns its channel
+// plus an unsubscribe func. Callers must defer-call unsubscribe once they
+// stop reading, or the channel and its slot in the broker leak.
+func (b *Broker) Subscribe(topic string) (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	b.mu.Lock()
+	subs, ok := b.topics[topic]
+	if !ok {
+		subs = make(map[chan Event]struct{})
+		b.topics[topic] = subs
+	}
+	subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if subs, ok := b.topics[topic]; ok {
+			delete(subs, ch)
+			if len(subs) == 0 {
+				delete(b.topics, topic)
+			}
+		}
+		close(ch)
+	}
+	return ch, unsubscribe
+}
+
+// Publish fans ev out to every current subscriber of topic. A subscriber
+// whose buffer is full misses ev rather than blocking the publisher; it
+// stays subscribed and receives later events once it has room.
+func (b *Broker) Publish(topic string, ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.topics[topic] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Stream writes events from ch to w as SSE frames until ch closes or r's
+// context is cancelled, sending a ": keep-alive" comment every keepAlive
+// interval so an upstream WriteTimeout doesn't fire on a quiet connection.
+// Callers own ch's lifetime (typically a Broker subscription) and should
+// unsubscribe once Stream returns.
+func Stream(w http.ResponseWriter, r *http.Request, ch <-chan Event, keepAlive time.Duration) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming unsupported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, open := <-ch:
+			if !open {
+				return nil
+			}
+			data, err := json.Marshal(ev.Data)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, data)
+			flusher.Flush()
+
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return r.Context().Err()
+		}
+	}
+}
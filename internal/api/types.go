@@ -1,5 +1,10 @@
 package api
 
+import (
+	"github.com/GonzoDMX/rag-anywhere/internal/config"
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
+)
+
 // ==========================================
 // 1. STANDARD ENVELOPE
 // ==========================================
@@ -25,6 +30,10 @@ type StatusResponse struct {
 	Version   string `json:"version"`
 	Port      string `json:"port"`
 	GoVersion string `json:"go_version"`
+
+	// Workers reports each configured pool's per-worker state/load, keyed
+	// "embed"/"vector". Omitted entries mean that pool isn't configured.
+	Workers map[string][]ipc.WorkerStat `json:"workers,omitempty"`
 }
 
 type LogsRequest struct {
@@ -41,11 +50,19 @@ type LogsRequest struct {
 
 // DocUploadResponse is returned immediately after a batch POST.
 type DocUploadResponse struct {
-	BatchID  string   `json:"batch_id"`
-	Status   string   `json:"status"`         // "queued"
-	Accepted []string `json:"accepted_files"` // List of filenames accepted for processing
-	Rejected []string `json:"rejected_files"` // List of filenames rejected (wrong type, too big)
-	Message  string   `json:"message"`
+	BatchID  string         `json:"batch_id"`
+	Status   string         `json:"status"`         // "queued"
+	Accepted []string       `json:"accepted_files"` // List of filenames accepted for processing
+	Rejected []RejectedFile `json:"rejected_files"`
+	Message  string         `json:"message"`
+}
+
+// RejectedFile reports why a single file in a batch upload was turned
+// away, mirroring ingest.IngestReject's reason taxonomy.
+type RejectedFile struct {
+	Filename string `json:"filename"`
+	Reason   string `json:"reason"` // ingest.RejectReason, or "open_error"/"save_error" for I/O failures
+	Detail   string `json:"detail,omitempty"`
 }
 
 // BatchStatusResponse is used for polling /api/v1/docs/batch/{id}
@@ -60,6 +77,27 @@ type BatchStatusResponse struct {
 	Failures    []string `json:"failure_reasons,omitempty"` // Detailed error per file if any
 }
 
+// DocEstimateRequest is used when estimating token cost for raw strings
+// instead of an uploaded file (JSON body path for /docs/estimate).
+type DocEstimateRequest struct {
+	Texts []string `json:"texts"`
+}
+
+// OversizeChunk flags a chunk that exceeds the stamped embed_context_length
+// and would be truncated by the embedding worker.
+type OversizeChunk struct {
+	Index int `json:"index"`
+	Len   int `json:"len"` // token count
+}
+
+// DocEstimateResponse is returned by /docs/estimate and /docs/batch/estimate.
+type DocEstimateResponse struct {
+	TotalTokens             int             `json:"total_tokens"`
+	ChunkCount              int             `json:"chunk_count"`
+	OversizeChunks          []OversizeChunk `json:"oversize_chunks"`
+	EstimatedEmbeddingCalls int             `json:"estimated_embedding_calls"`
+}
+
 // DocListRequest handles complex filtering for the document table.
 type DocListRequest struct {
 	Page     int                    `json:"page"`              // Default: 1
@@ -105,14 +143,21 @@ type DBUseRequest struct {
 }
 
 type DBInfoResponse struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	DocCount    int    `json:"doc_count"`
-	ChunkCount  int    `json:"chunk_count"`
-	VectorCount int    `json:"vector_count"` // Validated against Faiss
-	DiskSize    string `json:"disk_size"`    // Human readable
-	CreatedAt   string `json:"created_at"`
-	IsActive    bool   `json:"is_active"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	DocCount         int      `json:"doc_count"`
+	ChunkCount       int      `json:"chunk_count"`
+	VectorCount      int      `json:"vector_count"` // Validated against Faiss
+	DiskSize         string   `json:"disk_size"`    // Human readable
+	CreatedAt        string   `json:"created_at"`
+	IsActive         bool     `json:"is_active"`
+	SupportedFormats []string `json:"supported_formats,omitempty"` // What this binary's extractor registry can handle
+
+	// SchemaStatus is this DB's stamped model config diffed against
+	// config.CurrentDefaults (store.MigrationPlan.Status): "compatible",
+	// "update_available" (NER/rechunk only, doesn't block reads/writes),
+	// or "incompatible" (needs a re-embed via POST /api/v1/db/migrate).
+	SchemaStatus config.MigrationStatus `json:"schema_status,omitempty"`
 }
 
 type DBListResponse struct {
@@ -168,6 +213,15 @@ type SearchResult struct {
 	Score      float32                `json:"score"`
 	Metadata   map[string]interface{} `json:"metadata"`
 	Highlights []string               `json:"highlights,omitempty"` // For FTS/Keyword matches
+	GraphPath  string                 `json:"graph_path,omitempty"` // For KG search: seed entity -> matched entity chain
+
+	// The following are populated by hybrid search so the frontend can
+	// show why a result ranked where it did. SemanticRank/KeywordRank are
+	// 1-based positions in their retriever's result list, 0 if the chunk
+	// didn't appear there. RerankScore is only set in FusionRerank mode.
+	SemanticRank int     `json:"semantic_rank,omitempty"`
+	KeywordRank  int     `json:"keyword_rank,omitempty"`
+	RerankScore  float32 `json:"rerank_score,omitempty"`
 }
 
 type SearchResponse struct {
@@ -211,8 +265,21 @@ type SearchKeywordReq struct {
 	MustNotContain []string `json:"must_not_contain"` // NOT logic
 }
 
-// SearchHybridReq - Weighted Semantic + Keyword
+// SearchHybridReq - Semantic + Keyword, combined per FusionMode.
 type SearchHybridReq struct {
 	BaseSearchReq
-	Alpha float32 `json:"alpha"` // 0.0 to 1.0 (Weight of Semantic vs Keyword)
+	Alpha float32 `json:"alpha"` // FusionWeighted only: 0.0 to 1.0 (weight of semantic vs keyword)
+
+	// FusionMode selects how the semantic and keyword result lists are
+	// combined: FusionWeighted (default), FusionRRF, or FusionRerank. See
+	// the Fusion* constants in search_fusion.go.
+	FusionMode string `json:"fusion_mode,omitempty"`
+}
+
+// SearchKGReq - Entity co-occurrence graph traversal
+type SearchKGReq struct {
+	Query  string   `json:"query"`
+	Labels []string `json:"labels,omitempty"` // Restrict seed entity extraction to these labels
+	Hops   int      `json:"hops"`             // BFS depth over entity_edges, default 2
+	TopK   int      `json:"top_k"`            // Default: 10
 }
@@ -14,11 +14,12 @@ const (
 
 // DBState represents the config we read from the SQLite table
 type DBState struct {
-	EmbedID      string
-	EmbedVersion string
-	EmbedDim     int
-	NERID        string
-	NERVersion   string
+	EmbedID            string
+	EmbedVersion       string
+	EmbedDim           int
+	EmbedContextLength int
+	NERID              string
+	NERVersion         string
 }
 
 // CheckCompatibility compares the DB's state against the App's defaults
@@ -25,10 +25,12 @@ type ProcessingConfig struct {
 
 // SystemConfig represents the "Gold Standard" for this version of the App
 type SystemConfig struct {
-	AppVersion     string
-	EmbeddingModel ModelCard
-	NERModel       ModelCard
-	Processing     ProcessingConfig
+	AppVersion        string
+	EmbeddingModel    ModelCard
+	NERModel          ModelCard
+	Processing        ProcessingConfig
+	VectorBackend     string // "sqlite_brute" (default), "faiss", or "hnsw" — see store.NewVectorIndex
+	VectorIndexParams string // raw backend-specific tuning params (e.g. HNSW's M/efConstruction), as JSON
 }
 
 // CurrentDefaults defines the configuration for THIS version of the binary.
@@ -36,6 +38,9 @@ type SystemConfig struct {
 var CurrentDefaults = SystemConfig{
 	AppVersion: "0.1.0",
 
+	VectorBackend:     "sqlite_brute",
+	VectorIndexParams: "",
+
 	EmbeddingModel: ModelCard{
 		ID:            "google/embedding-gemma",
 		Version:       "1.0", // Increment this to force migration
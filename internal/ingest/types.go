@@ -1,6 +1,10 @@
 package ingest
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -20,64 +24,213 @@ var SupportedExtensions = map[string]bool{
 	".doc":  true,
 	".docx": true,
 
+	// Images (EXIF/IPTC extracted as synthetic text)
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".heic": true,
+	".heif": true,
+
+	// Audio (ID3/Vorbis tags extracted as synthetic text)
+	".mp3":  true,
+	".flac": true,
+	".ogg":  true,
+	".m4a":  true,
+
 	// Future: Code files
 	// ".py": true, ".go": true, ".js": true,
 }
 
-// IsSupported determines if a file should be processed based on its
-// content (Magic Numbers) and its name (Extension).
-func IsSupported(filename string, headerBytes []byte) bool {
-	// 1. Get the file extension (lowercase)
-	ext := strings.ToLower(filepath.Ext(filename))
+// RejectReason classifies why Validate turned a file away, so callers can
+// report more than just "unsupported" back to the user.
+type RejectReason string
+
+const (
+	WrongExtension     RejectReason = "wrong_extension"    // extension isn't on SupportedExtensions at all
+	SignatureMismatch  RejectReason = "signature_mismatch" // content doesn't match what the extension promises
+	EncryptedContainer RejectReason = "encrypted_container"
+	Truncated          RejectReason = "truncated" // file is shorter than its format needs to verify
+)
+
+// IngestReject explains why Validate turned a file away. It implements
+// error so callers that just want a message can use it directly.
+type IngestReject struct {
+	Reason   RejectReason
+	Filename string
+	Detail   string
+}
+
+func (r *IngestReject) Error() string {
+	if r.Detail == "" {
+		return fmt.Sprintf("%s: %s", r.Filename, r.Reason)
+	}
+	return fmt.Sprintf("%s: %s (%s)", r.Filename, r.Detail, r.Reason)
+}
+
+// Magic numbers Validate checks directly instead of trusting
+// http.DetectContentType, which only looks at the first 512 bytes and
+// can't tell a renamed ZIP from a real DOCX.
+var (
+	ole2Signature   = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1} // OLE2/CFB, used by legacy .doc
+	zipLocalFileSig = []byte{0x50, 0x4B, 0x03, 0x04}                         // "PK\x03\x04", ZIP local file header
+	pdfSignature    = []byte("%PDF-")
+)
 
-	// If the extension isn't even on our list, reject immediately.
-	// This saves us from trying to parse .exe or .iso files even if they mimic text.
+// headerPeekSize is how much of the file Validate reads up front — enough
+// to cover a ZIP local file header's fixed fields plus a generously long
+// first entry name (a docx peek, not a full central-directory parse).
+const headerPeekSize = 4096
+
+// pdfTailPeekSize is how far from EOF Validate looks for PDF's "%%EOF"
+// trailer; real PDF writers keep it within the last few hundred bytes.
+const pdfTailPeekSize = 1024
+
+// ooxmlLocalHeaderSize is the fixed-size portion of a ZIP local file
+// header, before the variable-length file name/extra fields.
+const ooxmlLocalHeaderSize = 30
+
+// Validate checks filename's extension against SupportedExtensions, then
+// sniffs file's content against that extension's real signature — OLE2 CFB
+// for .doc, a ZIP local file record naming "[Content_Types].xml" first for
+// .docx, and a "%PDF-"/"%%EOF" pair for .pdf — instead of trusting
+// http.DetectContentType's 512-byte MIME guess alone. Returns nil if
+// accepted. file's read position is restored to the start before return.
+func Validate(filename string, file io.ReadSeeker) *IngestReject {
+	ext := strings.ToLower(filepath.Ext(filename))
 	if !SupportedExtensions[ext] {
-		return false
+		return &IngestReject{Reason: WrongExtension, Filename: filename, Detail: "extension not in allow-list"}
 	}
+	defer file.Seek(0, io.SeekStart)
 
-	// 2. Sniff the MIME type from the first 512 bytes
-	// Go's http.DetectContentType is reliable for binaries, less so for text.
-	mime := http.DetectContentType(headerBytes)
+	header := make([]byte, headerPeekSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return &IngestReject{Reason: Truncated, Filename: filename, Detail: err.Error()}
+	}
+	header = header[:n]
 
-	// 3. Complex Logic for Specific Formats
+	switch ext {
+	case ".pdf":
+		return validatePDF(filename, file, header)
+	case ".doc":
+		return validateOLE2(filename, header)
+	case ".docx":
+		return validateOOXML(filename, header)
+	default:
+		return validateSniffed(filename, ext, header)
+	}
+}
 
-	// CASE A: PDF (Very Reliable)
-	if mime == "application/pdf" {
-		return true
+// IsSupported is the boolean form of Validate, kept for callers that only
+// need a yes/no answer.
+func IsSupported(filename string, file io.ReadSeeker) bool {
+	return Validate(filename, file) == nil
+}
+
+// validatePDF requires the literal "%PDF-" at offset 0 and a "%%EOF" sniff
+// within the trailing pdfTailPeekSize bytes — catching both a non-PDF
+// renamed to .pdf and a PDF whose upload got cut off mid-transfer.
+func validatePDF(filename string, file io.ReadSeeker, header []byte) *IngestReject {
+	if !bytes.HasPrefix(header, pdfSignature) {
+		return &IngestReject{Reason: SignatureMismatch, Filename: filename, Detail: "missing %PDF- header"}
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return &IngestReject{Reason: Truncated, Filename: filename, Detail: err.Error()}
+	}
+
+	tailSize := int64(pdfTailPeekSize)
+	if size < tailSize {
+		tailSize = size
+	}
+	if _, err := file.Seek(size-tailSize, io.SeekStart); err != nil {
+		return &IngestReject{Reason: Truncated, Filename: filename, Detail: err.Error()}
+	}
+	tail := make([]byte, tailSize)
+	if _, err := io.ReadFull(file, tail); err != nil {
+		return &IngestReject{Reason: Truncated, Filename: filename, Detail: err.Error()}
+	}
+
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return &IngestReject{Reason: Truncated, Filename: filename, Detail: "no %%EOF trailer found near end of file"}
+	}
+	return nil
+}
+
+// validateOLE2 requires the OLE2 compound-file magic that every legacy
+// .doc starts with.
+func validateOLE2(filename string, header []byte) *IngestReject {
+	if !bytes.HasPrefix(header, ole2Signature) {
+		return &IngestReject{Reason: SignatureMismatch, Filename: filename, Detail: "missing OLE2 compound file header"}
 	}
+	return nil
+}
 
-	// CASE B: DOCX (Tricky)
-	// DOCX files are actually ZIP archives containing XML.
-	// Go detects them as "application/zip". We must allow ZIP mime ONLY if ext is .docx
-	if mime == "application/zip" && ext == ".docx" {
-		return true
+// validateOOXML parses the ZIP local file header for the archive's first
+// entry and requires it to be named "[Content_Types].xml" — every
+// well-formed OOXML container (docx/xlsx/pptx) writes that entry first,
+// which a bare ZIP renamed to .docx won't.
+func validateOOXML(filename string, header []byte) *IngestReject {
+	if !bytes.HasPrefix(header, zipLocalFileSig) {
+		return &IngestReject{Reason: SignatureMismatch, Filename: filename, Detail: "missing ZIP local file signature"}
+	}
+	if len(header) < ooxmlLocalHeaderSize {
+		return &IngestReject{Reason: Truncated, Filename: filename, Detail: "zip local file header truncated"}
 	}
 
-	// CASE C: DOC (Old Word)
-	// Often detected as application/msword or application/octet-stream (OLE2)
-	if mime == "application/msword" || mime == "application/octet-stream" {
-		if ext == ".doc" {
-			return true
-		}
+	flags := binary.LittleEndian.Uint16(header[6:8])
+	if flags&0x1 != 0 {
+		return &IngestReject{Reason: EncryptedContainer, Filename: filename, Detail: "zip entry is encrypted"}
 	}
 
-	// CASE D: RTF (Rich Text)
-	// Can be "text/rtf", "application/rtf", or just "text/plain" depending on headers
-	if strings.Contains(mime, "rtf") || (strings.HasPrefix(mime, "text/plain") && ext == ".rtf") {
-		return true
+	nameLen := int(binary.LittleEndian.Uint16(header[26:28]))
+	if ooxmlLocalHeaderSize+nameLen > len(header) {
+		return &IngestReject{Reason: Truncated, Filename: filename, Detail: "zip entry name truncated"}
 	}
 
-	// CASE E: Plain Text / Markdown
-	// Go says "text/plain; charset=utf-8".
-	// We trust the extension map we checked in Step 1.
-	if strings.HasPrefix(mime, "text/plain") {
-		return true
+	name := string(header[ooxmlLocalHeaderSize : ooxmlLocalHeaderSize+nameLen])
+	if name != "[Content_Types].xml" {
+		return &IngestReject{Reason: SignatureMismatch, Filename: filename,
+			Detail: fmt.Sprintf("first zip entry is %q, want [Content_Types].xml", name)}
 	}
+	return nil
+}
+
+// validateSniffed handles every extension that isn't given dedicated deep
+// inspection above (rtf, text/markdown, images, audio), falling back to
+// http.DetectContentType the way IsSupported always used to.
+func validateSniffed(filename, ext string, header []byte) *IngestReject {
+	mime := http.DetectContentType(header)
+
+	switch {
+	// RTF: can be "text/rtf", "application/rtf", or just "text/plain"
+	// depending on headers.
+	case strings.Contains(mime, "rtf"):
+	case strings.HasPrefix(mime, "text/plain") && ext == ".rtf":
 
-	// If we got here, the file has a valid extension (like .docx)
-	// but the content didn't match what we expected (e.g., a text file renamed to .docx).
-	return false
+	// Plain Text / Markdown: Go says "text/plain; charset=utf-8". We trust
+	// the extension map already checked in Validate.
+	case strings.HasPrefix(mime, "text/plain"):
+
+	// Images: JPEG/PNG/GIF sniff reliably. HEIF/HEIC use an ISO base media
+	// container Go reports as "application/octet-stream", so we fall back
+	// to trusting the extension for those.
+	case strings.HasPrefix(mime, "image/jpeg"), strings.HasPrefix(mime, "image/png"), strings.HasPrefix(mime, "image/gif"):
+	case mime == "application/octet-stream" && (ext == ".heic" || ext == ".heif"):
+
+	// Audio: MP3 sniffs reliably; FLAC/OGG/M4A headers vary enough across
+	// encoders that we trust the extension once the magic bytes don't
+	// obviously belong to something else.
+	case strings.HasPrefix(mime, "audio/mpeg"):
+	case (ext == ".flac" || ext == ".ogg" || ext == ".m4a") && mime == "application/octet-stream":
+	case ext == ".ogg" && mime == "application/ogg", ext == ".m4a" && mime == "video/mp4":
+
+	default:
+		return &IngestReject{Reason: SignatureMismatch, Filename: filename, Detail: fmt.Sprintf("content sniffed as %q", mime)}
+	}
+	return nil
 }
 
 // GetProcessorType returns a standardized string for which parser to use.
@@ -93,6 +246,10 @@ func GetProcessorType(filename string) string {
 		return "rtf"
 	case ".md", ".markdown", ".txt":
 		return "text"
+	case ".jpg", ".jpeg", ".png", ".gif", ".heic", ".heif":
+		return "image"
+	case ".mp3", ".flac", ".ogg", ".m4a":
+		return "audio"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,147 @@
+package ingest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func mustReject(t *testing.T, filename string, content []byte, wantReason RejectReason) {
+	t.Helper()
+	reject := Validate(filename, bytes.NewReader(content))
+	if reject == nil {
+		t.Fatalf("Validate(%q) = nil, want reject reason %s", filename, wantReason)
+	}
+	if reject.Reason != wantReason {
+		t.Errorf("Validate(%q) reason = %s, want %s", filename, reject.Reason, wantReason)
+	}
+}
+
+func mustAccept(t *testing.T, filename string, content []byte) {
+	t.Helper()
+	if reject := Validate(filename, bytes.NewReader(content)); reject != nil {
+		t.Fatalf("Validate(%q) = %v, want accepted", filename, reject)
+	}
+}
+
+func TestValidateWrongExtension(t *testing.T) {
+	mustReject(t, "doc.exe", []byte("whatever"), WrongExtension)
+}
+
+func TestValidatePDF(t *testing.T) {
+	good := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("x"), 100)...)
+	good = append(good, []byte("\n%%EOF")...)
+	mustAccept(t, "report.pdf", good)
+
+	mustReject(t, "report.pdf", []byte("not a pdf at all"), SignatureMismatch)
+
+	noTrailer := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("x"), 2000)...)
+	mustReject(t, "report.pdf", noTrailer, Truncated)
+}
+
+func TestValidateOLE2LegacyDoc(t *testing.T) {
+	mustAccept(t, "old.doc", ole2Signature)
+	mustReject(t, "old.doc", []byte("PK\x03\x04not really ole2"), SignatureMismatch)
+}
+
+func TestValidateOOXMLDocx(t *testing.T) {
+	name := "[Content_Types].xml"
+	header := make([]byte, ooxmlLocalHeaderSize)
+	copy(header, zipLocalFileSig)
+	// bytes 26:28 are the little-endian file name length.
+	header[26] = byte(len(name))
+	header[27] = byte(len(name) >> 8)
+	header = append(header, []byte(name)...)
+	mustAccept(t, "contract.docx", header)
+
+	// Same shape, but the first entry isn't [Content_Types].xml — a bare
+	// ZIP renamed to .docx.
+	other := make([]byte, ooxmlLocalHeaderSize)
+	copy(other, zipLocalFileSig)
+	other[26] = byte(len("readme.txt"))
+	other = append(other, []byte("readme.txt")...)
+	mustReject(t, "contract.docx", other, SignatureMismatch)
+}
+
+func TestValidateOOXMLEncrypted(t *testing.T) {
+	name := "[Content_Types].xml"
+	header := make([]byte, ooxmlLocalHeaderSize)
+	copy(header, zipLocalFileSig)
+	header[6] = 0x01 // general purpose bit flag 0: encrypted
+	header[26] = byte(len(name))
+	header = append(header, []byte(name)...)
+	mustReject(t, "contract.docx", header, EncryptedContainer)
+}
+
+func TestValidateSniffedText(t *testing.T) {
+	mustAccept(t, "notes.md", []byte("# Heading\n\nSome plain text notes."))
+}
+
+func TestValidateSniffedMismatch(t *testing.T) {
+	// A ZIP archive renamed to .txt should fail the content sniff, even
+	// though the extension is on the allow-list.
+	zip := []byte{0x50, 0x4B, 0x03, 0x04, 0x00, 0x00}
+	mustReject(t, "notes.txt", zip, SignatureMismatch)
+}
+
+func TestIsSupported(t *testing.T) {
+	if IsSupported("doc.exe", bytes.NewReader([]byte("x"))) {
+		t.Error("IsSupported(doc.exe) = true, want false")
+	}
+	if !IsSupported("notes.txt", bytes.NewReader([]byte("hello"))) {
+		t.Error("IsSupported(notes.txt) = false, want true")
+	}
+}
+
+func TestValidateRestoresReadPosition(t *testing.T) {
+	r := bytes.NewReader([]byte("hello world"))
+	if _, err := r.Seek(3, 0); err != nil {
+		t.Fatal(err)
+	}
+	Validate("notes.txt", r)
+
+	pos, err := r.Seek(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 0 {
+		t.Errorf("read position after Validate = %d, want 0", pos)
+	}
+}
+
+func TestGetProcessorType(t *testing.T) {
+	cases := map[string]string{
+		"report.pdf":    "pdf",
+		"contract.doc":  "word",
+		"contract.docx": "word",
+		"memo.rtf":      "rtf",
+		"notes.md":      "text",
+		"notes.txt":     "text",
+		"photo.jpg":     "image",
+		"photo.heic":    "image",
+		"song.mp3":      "audio",
+		"song.flac":     "audio",
+		"data.bin":      "unknown",
+	}
+	for filename, want := range cases {
+		if got := GetProcessorType(filename); got != want {
+			t.Errorf("GetProcessorType(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestValidateSniffedHeicTrustsExtension(t *testing.T) {
+	// HEIC/HEIF sniff as application/octet-stream; validateSniffed must
+	// trust the extension for those rather than rejecting every one.
+	header := bytes.Repeat([]byte{0x00}, 32)
+	mustAccept(t, "photo.heic", header)
+	mustAccept(t, "photo.heif", header)
+}
+
+func TestValidateSniffedRTFAsTextPlain(t *testing.T) {
+	// Some encoders emit RTF that Go's content sniffer reports as
+	// text/plain instead of an rtf-flavored mime; validateSniffed must
+	// still accept it for .rtf.
+	rtf := []byte(strings.Repeat("plain prose, no {\\rtf1 marker}", 1))
+	mustAccept(t, "memo.rtf", rtf)
+}
@@ -0,0 +1,135 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+// FramingMode selects how a PythonService exchanges embedding vectors with
+// its worker, beyond the original one-JSON-object-per-line protocol. Only
+// ProcessEmbedBatch is framing-aware — every other call still goes through
+// Process's plain JSON-line protocol regardless of framing, since requests
+// and non-vector responses are small enough that JSON overhead doesn't
+// matter there.
+type FramingMode int
+
+const (
+	// FramingLines is the original protocol: the response is a single
+	// JSON line, vectors included inline as a nested number array.
+	FramingLines FramingMode = iota
+
+	// FramingLengthPrefixed has the worker answer with a \n-terminated
+	// JSON header (embedResultHeader) naming how many raw bytes follow,
+	// then exactly that many bytes of little-endian float32 vector data.
+	// This skips a JSON encode/decode of every float for large batches.
+	FramingLengthPrefixed
+
+	// FramingSharedMemory goes one step further: the worker writes the
+	// vectors to a temp file and the header names its path instead of
+	// inlining the bytes. The Go side mmaps that file and reads the
+	// floats directly, with no copy across the pipe at all.
+	FramingSharedMemory
+)
+
+// framingName is what negotiateFraming sends the worker so it knows which
+// response shape to use; FramingLines needs no negotiation.
+var framingName = map[FramingMode]string{
+	FramingLengthPrefixed: "length_prefixed",
+	FramingSharedMemory:   "shared_memory",
+}
+
+// embedResultHeader is the JSON line that precedes (or names) the binary
+// vector payload under FramingLengthPrefixed/FramingSharedMemory.
+type embedResultHeader struct {
+	Op         string `json:"op"`
+	PayloadLen int    `json:"payload_len,omitempty"` // FramingLengthPrefixed: raw bytes following this line
+	ShmPath    string `json:"shm_path,omitempty"`    // FramingSharedMemory: temp file holding the payload
+	Count      int    `json:"count"`                 // number of vectors
+	Dimension  int    `json:"dimension"`             // floats per vector
+	Error      string `json:"error,omitempty"`
+}
+
+// negotiateFraming tells the worker which response shape ProcessEmbedBatch
+// expects, before any real traffic flows.
+func (s *PythonService) negotiateFraming() error {
+	var resp struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	req := map[string]string{"op": "negotiate_framing", "framing": framingName[s.framing]}
+	if err := s.Process(context.Background(), req, &resp); err != nil {
+		return fmt.Errorf("negotiate framing: %w", err)
+	}
+	if resp.Status != "ok" {
+		return fmt.Errorf("worker rejected framing %q: %s", framingName[s.framing], resp.Error)
+	}
+	return nil
+}
+
+// ProcessEmbedBatch sends an embed request and reads the vectors back using
+// this service's negotiated framing. Under FramingLines it's equivalent to
+// Process; the other two modes skip JSON-decoding the vectors themselves,
+// which is where the cost is for large batches.
+func (s *PythonService) ProcessEmbedBatch(ctx context.Context, req models.WorkerEmbedRequest) (models.WorkerEmbedResponse, error) {
+	var resp models.WorkerEmbedResponse
+	if s.framing == FramingLines {
+		err := s.Process(ctx, req, &resp)
+		return resp, err
+	}
+
+	val, err := s.runWithCancel(ctx, req, s.readFramedEmbedResult)
+	if err != nil {
+		return resp, err
+	}
+	return val.(models.WorkerEmbedResponse), nil
+}
+
+// readFramedEmbedResult reads one embedResultHeader line followed by its
+// vector payload (inline bytes, or an mmap'd shm file, per s.framing) and
+// assembles a models.WorkerEmbedResponse from it.
+func (s *PythonService) readFramedEmbedResult(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var hdr embedResultHeader
+	if err := json.Unmarshal(line, &hdr); err != nil {
+		return nil, fmt.Errorf("invalid embed result header: %s (err: %w)", string(line), err)
+	}
+	if hdr.Error != "" {
+		return models.WorkerEmbedResponse{Error: hdr.Error}, nil
+	}
+
+	var flat []float32
+	switch s.framing {
+	case FramingLengthPrefixed:
+		buf := make([]byte, hdr.PayloadLen)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("reading vector payload: %w", err)
+		}
+		flat = models.BytesToFloat32(buf)
+	case FramingSharedMemory:
+		flat, err = readShmFloats(hdr.ShmPath, hdr.Count*hdr.Dimension)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported framing mode %d for embed batch", s.framing)
+	}
+
+	want := hdr.Count * hdr.Dimension
+	if len(flat) != want {
+		return nil, fmt.Errorf("embed result payload has %d floats, want %d (count=%d dim=%d)", len(flat), want, hdr.Count, hdr.Dimension)
+	}
+
+	vectors := make([][]float32, hdr.Count)
+	for i := 0; i < hdr.Count; i++ {
+		vectors[i] = flat[i*hdr.Dimension : (i+1)*hdr.Dimension]
+	}
+	return models.WorkerEmbedResponse{Vectors: vectors}, nil
+}
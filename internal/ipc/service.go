@@ -2,6 +2,7 @@ package ipc
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,8 +12,15 @@ import (
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// heartbeatInterval is how often an idle PythonService pings its child to
+// catch a hang that isn't surfaced by a live Process call (stdin.Write and
+// stdout.ReadBytes only notice a dead process the next time something
+// actually asks it to do work).
+const heartbeatInterval = 10 * time.Second
+
 // =================================================================================
 // 1. HELPER: Environment Detection
 // =================================================================================
@@ -43,26 +51,101 @@ func getPythonCommand() string {
 	return "python3"
 }
 
+// Client is the common interface both PythonService and WorkerPool satisfy.
+// Code that only needs to send a request and read back a response (store
+// migrations, the KG backfill, etc.) should depend on this instead of a
+// concrete worker type so it works with either a single worker or a pool.
+// ctx bounds how long the caller is willing to wait; since stdin/stdout
+// gives us no way to cancel an in-flight read, a cancelled/expired ctx
+// kills the underlying worker process rather than just returning early.
+type Client interface {
+	Process(ctx context.Context, req interface{}, resp interface{}) error
+}
+
+// WorkerState describes where a PythonService is in its lifecycle, as
+// reported by WorkerPool.PoolStats.
+type WorkerState string
+
+const (
+	StateStarting   WorkerState = "starting"
+	StateReady      WorkerState = "ready"
+	StateBusy       WorkerState = "busy"
+	StateDead       WorkerState = "dead"
+	StateRestarting WorkerState = "restarting"
+)
+
 // =================================================================================
 // 2. SINGLE WORKER (PythonService)
 // =================================================================================
 
-// PythonService manages a single background Python process
+// PythonService manages a single background Python process, transparently
+// restarting it if it crashes. scriptPath is kept around (rather than only
+// used once, in NewPythonService) so a restart can relaunch the exact same
+// script.
 type PythonService struct {
+	scriptPath string
+	framing    FramingMode // negotiated once at startup; see framing.go
+
+	ioMutex sync.Mutex // serializes stdin.Write + stdout.ReadBytes; the pipe has no request IDs to multiplex on
 	cmd     *exec.Cmd
 	stdin   io.WriteCloser
-	stdout  *bufio.Reader // Changed from Scanner to Reader for large payloads
-	mutex   sync.Mutex    // Ensures we don't send overlapping requests to the same process
-	running bool
+	stdout  *bufio.Reader
+
+	// cmdPtr mirrors cmd but is readable without ioMutex, so killing a
+	// worker on a context timeout never has to wait behind a blocked read.
+	cmdPtr atomic.Pointer[exec.Cmd]
+
+	stateMu sync.Mutex
+	state   WorkerState
+
+	inFlight   int64 // atomic: count of Process calls currently in flight, for least-in-flight selection
+	restarting atomic.Bool
+	closing    atomic.Bool
+
+	stopHeartbeat chan struct{}
 }
 
-// NewPythonService starts a python worker
+// NewPythonService starts a python worker using the original one-JSON-
+// object-per-line protocol (FramingLines).
 func NewPythonService(scriptPath string) (*PythonService, error) {
+	return NewPythonServiceWithFraming(scriptPath, FramingLines)
+}
+
+// NewPythonServiceWithFraming starts a python worker and, for framing
+// modes other than FramingLines, negotiates that framing with it before
+// returning — see framing.go for what each mode buys ProcessEmbedBatch.
+func NewPythonServiceWithFraming(scriptPath string, framing FramingMode) (*PythonService, error) {
+	s := &PythonService{
+		scriptPath:    scriptPath,
+		framing:       framing,
+		stopHeartbeat: make(chan struct{}),
+	}
+	s.setState(StateStarting)
+
+	if err := s.start(); err != nil {
+		return nil, err
+	}
+
+	if framing != FramingLines {
+		if err := s.negotiateFraming(); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	go s.heartbeatLoop()
+	return s, nil
+}
+
+// start launches (or relaunches) the child process and swaps it in as the
+// service's live stdin/stdout, then spawns the goroutine that watches for
+// it exiting unexpectedly.
+func (s *PythonService) start() error {
 	pythonCmd := getPythonCommand()
 
 	// "-u" flag forces Python to use unbuffered binary stdout.
 	// This prevents the program from hanging waiting for output buffer to fill.
-	cmd := exec.Command(pythonCmd, "-u", scriptPath)
+	cmd := exec.Command(pythonCmd, "-u", s.scriptPath)
 
 	// Set working dir to project root to ensure imports work if needed
 	cwd, _ := os.Getwd()
@@ -71,83 +154,276 @@ func NewPythonService(scriptPath string) (*PythonService, error) {
 	// Pipes to talk to the child process
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
 	// Redirect stderr to parent stderr so we can see Python logs in Go console
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start python script %s: %w", scriptPath, err)
+		return fmt.Errorf("failed to start python script %s: %w", s.scriptPath, err)
 	}
 
-	return &PythonService{
-		cmd:     cmd,
-		stdin:   stdin,
-		stdout:  bufio.NewReader(stdout),
-		running: true,
-	}, nil
+	s.ioMutex.Lock()
+	s.cmd = cmd
+	s.stdin = stdin
+	s.stdout = bufio.NewReader(stdout)
+	s.ioMutex.Unlock()
+	s.cmdPtr.Store(cmd)
+
+	s.setState(StateReady)
+	go s.waitForExit(cmd)
+	return nil
+}
+
+// waitForExit blocks until cmd exits, then kicks off a restart unless the
+// service is being closed intentionally or a restart has already replaced
+// cmd with a newer process by the time Wait returns.
+func (s *PythonService) waitForExit(cmd *exec.Cmd) {
+	cmd.Wait()
+
+	if s.closing.Load() {
+		return
+	}
+	if s.cmdPtr.Load() != cmd {
+		return
+	}
+	s.triggerRestart()
 }
 
-// Process sends a request to Python and waits for the response
-// The payload can be any struct that serializes to JSON
-func (s *PythonService) Process(req interface{}, resp interface{}) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+// triggerRestart marks the worker dead and relaunches it, reusing the
+// original scriptPath. Safe to call concurrently — a Process failure and
+// the waitForExit watcher can both observe the same crash, but only one
+// restart actually runs.
+func (s *PythonService) triggerRestart() {
+	if !s.restarting.CompareAndSwap(false, true) {
+		return
+	}
+
+	s.setState(StateDead)
+	go func() {
+		defer s.restarting.Store(false)
+
+		if s.closing.Load() {
+			return
+		}
+		s.setState(StateRestarting)
+
+		// Best-effort cleanup in case the process is still limping along
+		// rather than fully exited (e.g. we got here from a Process-side
+		// write/read failure, not waitForExit).
+		s.ioMutex.Lock()
+		if s.cmd != nil && s.cmd.Process != nil {
+			s.cmd.Process.Kill()
+		}
+		s.ioMutex.Unlock()
+
+		if err := s.start(); err != nil {
+			s.setState(StateDead)
+		}
+	}()
+}
 
-	if !s.running {
-		return fmt.Errorf("python worker is not running")
+// heartbeatLoop pings an idle worker so a hang is caught even when nothing
+// is actively sending it requests.
+func (s *PythonService) heartbeatLoop() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopHeartbeat:
+			return
+		case <-ticker.C:
+			if s.getState() != StateReady {
+				continue
+			}
+			var resp interface{}
+			ctx, cancel := context.WithTimeout(context.Background(), heartbeatInterval)
+			s.Process(ctx, map[string]string{"op": "ping"}, &resp)
+			cancel()
+		}
 	}
+}
 
-	// 1. Encode Request to JSON
-	reqBytes, err := json.Marshal(req)
+// Process sends a request to Python and waits for the response. The
+// payload can be any struct that serializes to JSON. ctx bounds how long
+// to wait; since a blocked stdout.ReadBytes can't be cancelled directly, an
+// expired ctx kills the worker process instead, which waitForExit then
+// restarts.
+func (s *PythonService) Process(ctx context.Context, req interface{}, resp interface{}) error {
+	val, err := s.runWithCancel(ctx, req, func(r *bufio.Reader) (interface{}, error) {
+		// We use ReadBytes('\n') instead of Scanner because vector
+		// batches can be huge (>64KB).
+		return r.ReadBytes('\n')
+	})
 	if err != nil {
-		return fmt.Errorf("marshalling error: %w", err)
+		return err
 	}
 
-	// 2. Write to Python (add newline so Python's sys.stdin.readline() knows to stop reading)
-	_, err = s.stdin.Write(append(reqBytes, '\n'))
-	if err != nil {
-		return fmt.Errorf("failed to write to python: %w", err)
+	data := val.([]byte)
+	if err := json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("python returned invalid JSON: %s (err: %v)", string(data), err)
 	}
+	return nil
+}
 
-	// 3. Read Response from Python
-	// We use ReadBytes('\n') instead of Scanner because vector batches can be huge (>64KB)
-	respBytes, err := s.stdout.ReadBytes('\n')
+// ProcessStream sends req the same way Process does, but reads repeated
+// newline-delimited JSON frames off the response instead of one — each
+// frame is forwarded to out until a frame with "done": true, which is
+// consumed but not forwarded. Used for calls that report intermediate
+// progress (e.g. embed_progress events during a large batch) rather than a
+// single terminal response. Closes out before returning, so callers can
+// range over it.
+func (s *PythonService) ProcessStream(ctx context.Context, req interface{}, out chan<- json.RawMessage) error {
+	defer close(out)
+
+	_, err := s.runWithCancel(ctx, req, func(r *bufio.Reader) (interface{}, error) {
+		for {
+			line, err := r.ReadBytes('\n')
+			if err != nil {
+				return nil, err
+			}
+
+			var probe struct {
+				Done bool `json:"done"`
+			}
+			if err := json.Unmarshal(line, &probe); err != nil {
+				return nil, fmt.Errorf("python returned invalid JSON: %s (err: %v)", string(line), err)
+			}
+			if probe.Done {
+				return nil, nil
+			}
+
+			frame := make(json.RawMessage, len(line))
+			copy(frame, line)
+			out <- frame
+		}
+	})
+	return err
+}
+
+// ioResult carries runWithCancel's outcome through its internal done
+// channel: either the value readResp produced, or an error.
+type ioResult struct {
+	val interface{}
+	err error
+}
+
+// runWithCancel writes req as a JSON line to Python and hands the response
+// off to readResp, which gets exclusive access to s.stdout to consume
+// however many bytes the caller's protocol needs (a single JSON line for
+// Process, or a header line plus trailing binary payload for
+// ProcessEmbedBatch's framed modes). ctx bounds how long to wait; since a
+// blocked read can't be cancelled directly, an expired ctx kills the
+// worker process instead, which waitForExit then restarts.
+func (s *PythonService) runWithCancel(ctx context.Context, req interface{}, readResp func(*bufio.Reader) (interface{}, error)) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s.getState() == StateDead {
+		return nil, fmt.Errorf("python worker is not running")
+	}
+
+	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return fmt.Errorf("failed to read from python (worker might have crashed): %w", err)
+		return nil, fmt.Errorf("marshalling error: %w", err)
 	}
 
-	// 4. Decode Response
-	if err := json.Unmarshal(respBytes, resp); err != nil {
-		return fmt.Errorf("python returned invalid JSON: %s (err: %v)", string(respBytes), err)
+	atomic.AddInt64(&s.inFlight, 1)
+	defer atomic.AddInt64(&s.inFlight, -1)
+
+	done := make(chan ioResult, 1)
+
+	go func() {
+		s.ioMutex.Lock()
+		s.setState(StateBusy)
+
+		// Write to Python (add newline so Python's sys.stdin.readline() knows to stop reading)
+		_, werr := s.stdin.Write(append(reqBytes, '\n'))
+
+		var val interface{}
+		var rerr error
+		if werr == nil {
+			val, rerr = readResp(s.stdout)
+		}
+		if werr == nil && rerr == nil {
+			s.setState(StateReady)
+		}
+		s.ioMutex.Unlock()
+
+		switch {
+		case werr != nil:
+			done <- ioResult{nil, fmt.Errorf("failed to write to python: %w", werr)}
+		case rerr != nil:
+			done <- ioResult{nil, fmt.Errorf("failed to read from python (worker might have crashed): %w", rerr)}
+		default:
+			done <- ioResult{val, nil}
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		if cmd := s.cmdPtr.Load(); cmd != nil && cmd.Process != nil {
+			cmd.Process.Kill()
+		}
+		<-done // avoid leaking the goroutine; its result is discarded
+		return nil, ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			s.triggerRestart()
+			return nil, r.err
+		}
+		return r.val, nil
 	}
+}
 
-	return nil
+// State reports this worker's current lifecycle state.
+func (s *PythonService) State() WorkerState {
+	return s.getState()
+}
+
+// InFlight reports how many Process calls are currently outstanding
+// against this worker.
+func (s *PythonService) InFlight() int64 {
+	return atomic.LoadInt64(&s.inFlight)
+}
+
+func (s *PythonService) setState(st WorkerState) {
+	s.stateMu.Lock()
+	s.state = st
+	s.stateMu.Unlock()
+}
+
+func (s *PythonService) getState() WorkerState {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.state
 }
 
 // Close gracefully shuts down the worker
 func (s *PythonService) Close() {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	s.closing.Store(true)
+	close(s.stopHeartbeat)
 
-	if !s.running {
-		return
-	}
+	s.ioMutex.Lock()
+	defer s.ioMutex.Unlock()
 
-	s.running = false
 	// Closing stdin usually signals EOF to Python, causing it to exit the loop
-	_ = s.stdin.Close()
+	if s.stdin != nil {
+		_ = s.stdin.Close()
+	}
 
 	// Force kill if it doesn't exit
-	if s.cmd.Process != nil {
+	if s.cmd != nil && s.cmd.Process != nil {
 		_ = s.cmd.Process.Kill()
 	}
+	s.setState(StateDead)
 }
 
 // =================================================================================
@@ -158,7 +434,6 @@ func (s *PythonService) Close() {
 // to allow parallel processing (Horizontal Scaling).
 type WorkerPool struct {
 	workers []*PythonService
-	counter uint64 // Used for Round-Robin load balancing
 }
 
 // NewWorkerPool starts 'count' copies of the given python script
@@ -181,24 +456,49 @@ func NewWorkerPool(scriptPath string, count int) (*WorkerPool, error) {
 		workers = append(workers, w)
 	}
 
-	return &WorkerPool{
-		workers: workers,
-		counter: 0,
-	}, nil
+	return &WorkerPool{workers: workers}, nil
 }
 
-// Process distributes the task to the next available worker using Round-Robin
-func (p *WorkerPool) Process(req interface{}, resp interface{}) error {
+// Process routes the task to whichever live worker currently has the
+// fewest requests in flight, so one long embed batch doesn't stall short
+// requests behind it on a different, idle worker.
+func (p *WorkerPool) Process(ctx context.Context, req interface{}, resp interface{}) error {
 	if len(p.workers) == 0 {
 		return fmt.Errorf("no workers available")
 	}
 
-	// Atomic increment ensures thread-safety when selecting a worker
-	current := atomic.AddUint64(&p.counter, 1)
-	workerIndex := current % uint64(len(p.workers))
+	var best *PythonService
+	var bestLoad int64 = -1
+	for _, w := range p.workers {
+		if w.State() == StateDead {
+			continue
+		}
+		if load := w.InFlight(); bestLoad == -1 || load < bestLoad {
+			best, bestLoad = w, load
+		}
+	}
+	if best == nil {
+		return fmt.Errorf("no healthy workers available")
+	}
+
+	return best.Process(ctx, req, resp)
+}
 
-	selectedWorker := p.workers[workerIndex]
-	return selectedWorker.Process(req, resp)
+// WorkerStat is one worker's reported state and current load, as returned
+// by PoolStats.
+type WorkerStat struct {
+	State    WorkerState `json:"state"`
+	InFlight int64       `json:"in_flight"`
+}
+
+// PoolStats reports the live state of every worker in the pool, for
+// HandleStatus to surface.
+func (p *WorkerPool) PoolStats() []WorkerStat {
+	stats := make([]WorkerStat, len(p.workers))
+	for i, w := range p.workers {
+		stats[i] = WorkerStat{State: w.State(), InFlight: w.InFlight()}
+	}
+	return stats
 }
 
 // Close shuts down all workers in the pool
@@ -207,3 +507,8 @@ func (p *WorkerPool) Close() {
 		w.Close()
 	}
 }
+
+var (
+	_ Client = (*PythonService)(nil)
+	_ Client = (*WorkerPool)(nil)
+)
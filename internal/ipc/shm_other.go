@@ -0,0 +1,12 @@
+//go:build windows
+
+package ipc
+
+import "fmt"
+
+// readShmFloats isn't implemented on windows (syscall.Mmap isn't available
+// there); negotiateFraming's caller should never select
+// FramingSharedMemory on this platform.
+func readShmFloats(path string, wantFloats int) ([]float32, error) {
+	return nil, fmt.Errorf("shared-memory framing is not supported on windows")
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// readShmFloats mmaps the temp file the worker wrote wantFloats
+// little-endian float32 values into, reads them via unsafe.Slice directly
+// on the mapping (no copy through a read() syscall), and removes the file
+// once it's been copied out — this call is the file's only reader.
+func readShmFloats(path string, wantFloats int) ([]float32, error) {
+	if wantFloats == 0 {
+		os.Remove(path)
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening shm payload %s: %w", path, err)
+	}
+	defer f.Close()
+	defer os.Remove(path)
+
+	wantBytes := wantFloats * 4
+	data, err := syscall.Mmap(int(f.Fd()), 0, wantBytes, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap shm payload %s: %w", path, err)
+	}
+	defer syscall.Munmap(data)
+
+	floats := unsafe.Slice((*float32)(unsafe.Pointer(&data[0])), wantFloats)
+	out := make([]float32, wantFloats)
+	copy(out, floats)
+	return out, nil
+}
@@ -84,3 +84,17 @@ type WorkerVectorResponse struct {
 	Scores  []float32 `json:"scores,omitempty"`  // Similarity scores
 	Error   string    `json:"error,omitempty"`
 }
+
+// ==========================================
+// 4. RERANK WORKER (Cross-Encoder)
+// ==========================================
+
+type WorkerRerankRequest struct {
+	Query string   `json:"query"`
+	Texts []string `json:"texts"` // Candidate chunk contents, same order as the caller's candidate list
+}
+
+type WorkerRerankResponse struct {
+	Scores []float32 `json:"scores,omitempty"` // One score per input text, same order as Texts
+	Error  string    `json:"error,omitempty"`
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// BoundaryDetector locates sentence boundaries in text, letting
+// CreateSubChunks snap its overlap window to a complete sentence instead
+// of cutting mid-sentence — the main source of duplicate/partial GLiNER
+// entity spans after the dedup pass merges adjacent chunks.
+type BoundaryDetector interface {
+	// Boundaries returns the byte offsets in text where the next sentence
+	// starts (past each sentence terminator and the whitespace after it),
+	// in ascending order — each one a valid split point.
+	Boundaries(text string) []int
+}
+
+// sentenceEndRegex matches a run of sentence-terminating punctuation
+// followed by whitespace.
+var sentenceEndRegex = regexp.MustCompile(`[.!?]+\s+`)
+
+// commonAbbreviations guards RegexBoundaryDetector against treating
+// "Dr. Smith" or "e.g. this" as a sentence end: if the word immediately
+// before the terminator is one of these (lowercased, punctuation
+// included), the match is skipped.
+var commonAbbreviations = map[string]bool{
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "prof.": true,
+	"sr.": true, "jr.": true, "st.": true, "vs.": true, "etc.": true,
+	"e.g.": true, "i.e.": true, "fig.": true, "no.": true, "approx.": true,
+	"inc.": true, "ltd.": true, "co.": true,
+}
+
+// RegexBoundaryDetector is the default BoundaryDetector: a "[.!?]+\s+"
+// sniff with an abbreviation guard. It doesn't need a trained model,
+// unlike a real sentence-splitting library.
+type RegexBoundaryDetector struct{}
+
+func (RegexBoundaryDetector) Boundaries(text string) []int {
+	var bounds []int
+	for _, loc := range sentenceEndRegex.FindAllStringIndex(text, -1) {
+		punctEnd := loc[0]
+		for punctEnd < loc[1] && strings.ContainsRune(".!?", rune(text[punctEnd])) {
+			punctEnd++
+		}
+
+		wordStart := loc[0]
+		for wordStart > 0 && !unicode.IsSpace(rune(text[wordStart-1])) {
+			wordStart--
+		}
+
+		if commonAbbreviations[strings.ToLower(text[wordStart:punctEnd])] {
+			continue
+		}
+		bounds = append(bounds, loc[1])
+	}
+	return bounds
+}
+
+var _ BoundaryDetector = RegexBoundaryDetector{}
@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegexBoundaryDetectorFindsSentenceEnds(t *testing.T) {
+	text := "First sentence. Second sentence! Third one? Fourth."
+	bounds := (RegexBoundaryDetector{}).Boundaries(text)
+
+	// Every boundary offset should land right after one of the three
+	// terminators followed by whitespace ("Fourth." has no trailing
+	// whitespace, so it isn't reported).
+	want := []string{"Second", "Third", "Fourth"}
+	if len(bounds) != len(want) {
+		t.Fatalf("got %d boundaries, want %d: %v", len(bounds), len(want), bounds)
+	}
+	for i, b := range bounds {
+		if !strings.HasPrefix(text[b:], want[i]) {
+			t.Errorf("boundary %d = %d, want text[%d:] to start with %q, got %q", i, b, b, want[i], text[b:b+len(want[i])])
+		}
+	}
+}
+
+func TestRegexBoundaryDetectorGuardsAbbreviations(t *testing.T) {
+	text := "Dr. Smith met Mr. Jones at 3 p.m. They left."
+	bounds := (RegexBoundaryDetector{}).Boundaries(text)
+
+	// "Dr." and "Mr." must not be treated as sentence ends. "p.m." isn't
+	// in the abbreviation list, so one boundary (before "They") is found;
+	// the final "left." has no trailing whitespace and isn't reported.
+	for _, b := range bounds {
+		if strings.HasPrefix(text[b:], "Smith") || strings.HasPrefix(text[b:], "Jones") {
+			t.Errorf("boundary at %d incorrectly split after an abbreviation: %q", b, text[:b])
+		}
+	}
+}
+
+func TestRegexBoundaryDetectorEmptyText(t *testing.T) {
+	if bounds := (RegexBoundaryDetector{}).Boundaries(""); len(bounds) != 0 {
+		t.Errorf("Boundaries(\"\") = %v, want empty", bounds)
+	}
+}
+
+func TestRegexBoundaryDetectorNoTerminators(t *testing.T) {
+	if bounds := (RegexBoundaryDetector{}).Boundaries("no terminators here at all"); len(bounds) != 0 {
+		t.Errorf("Boundaries with no terminators = %v, want empty", bounds)
+	}
+}
+
+func TestSnapToSentenceBoundaryPrefersClosestMatch(t *testing.T) {
+	// 6 words, each costing 1 token. Measured back from j=6, idx 1 costs
+	// 5 tokens (diff 2 from overlap=3) and idx 4 costs 2 tokens (diff 1)
+	// — both within slack=2, but idx 4 is strictly closer.
+	prefix := []int{0, 1, 2, 3, 4, 5, 6}
+	isBoundary := []bool{false, true, false, false, true, false}
+
+	got := snapToSentenceBoundary(prefix, isBoundary, 0, 6, 3, 3, 2)
+	if got != 4 {
+		t.Errorf("snapToSentenceBoundary = %d, want 4 (closest boundary to overlap=3)", got)
+	}
+}
+
+func TestSnapToSentenceBoundaryFallsBackWhenNoneQualify(t *testing.T) {
+	prefix := []int{0, 1, 2, 3, 4, 5, 6}
+	isBoundary := []bool{false, false, false, false, false, false}
+
+	got := snapToSentenceBoundary(prefix, isBoundary, 0, 6, 3, 3, 1)
+	if got != 3 {
+		t.Errorf("snapToSentenceBoundary with no boundaries = %d, want unchanged target 3", got)
+	}
+}
+
+func TestCreateSubChunksSnapsOverlapToSentenceBoundary(t *testing.T) {
+	// Build text where a raw token-count overlap would land mid-sentence,
+	// but a sentence boundary sits a few tokens away within slack.
+	text := "Alpha bravo charlie delta. Echo foxtrot golf hotel. India juliet kilo lima."
+	chunks := CreateSubChunks(text, RegexTokenCounter{}, nil, 8, 4)
+
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want at least 2", len(chunks))
+	}
+	// The second chunk's start should land right after a sentence
+	// terminator + whitespace, not mid-sentence.
+	second := chunks[1].Text
+	trimmed := strings.TrimSpace(second)
+	if len(trimmed) == 0 {
+		t.Fatal("second chunk is empty")
+	}
+	firstRune := trimmed[0]
+	if firstRune < 'A' || firstRune > 'Z' {
+		t.Errorf("second chunk %q doesn't start at a capitalized sentence-initial word", second)
+	}
+}
+
+func TestCreateSubChunksFallsBackToRawOverlapWithoutBoundary(t *testing.T) {
+	// No punctuation at all: snapToSentenceBoundary should never find a
+	// qualifying index, so behavior matches the pre-chunk3-5 raw overlap
+	// step-back exactly.
+	text := strings.Repeat("word ", 50)
+	withDetector := CreateSubChunks(text, RegexTokenCounter{}, RegexBoundaryDetector{}, 10, 3)
+	withoutBoundaries := CreateSubChunks(text, RegexTokenCounter{}, noBoundaries{}, 10, 3)
+
+	if len(withDetector) != len(withoutBoundaries) {
+		t.Fatalf("got %d chunks with RegexBoundaryDetector, %d with no boundaries at all; want equal since this text has no sentence terminators", len(withDetector), len(withoutBoundaries))
+	}
+	for i := range withDetector {
+		if withDetector[i].Text != withoutBoundaries[i].Text {
+			t.Errorf("chunk %d differs: %q vs %q", i, withDetector[i].Text, withoutBoundaries[i].Text)
+		}
+	}
+}
+
+// noBoundaries is a BoundaryDetector that never finds one, used to pin
+// down CreateSubChunks' fallback behavior independent of
+// RegexBoundaryDetector's own correctness.
+type noBoundaries struct{}
+
+func (noBoundaries) Boundaries(string) []int { return nil }
+
+var _ BoundaryDetector = noBoundaries{}
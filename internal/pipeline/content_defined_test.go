@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateContentDefinedSubChunksClampsToRange(t *testing.T) {
+	text := strings.Repeat("the quick brown fox jumps over the lazy dog ", 200)
+	chunks := CreateContentDefinedSubChunks(text, 20, 10, 40)
+
+	if len(chunks) == 0 {
+		t.Fatal("got 0 chunks for non-empty text")
+	}
+	for i, c := range chunks {
+		n := countTokens(c.Text)
+		if n > 40 {
+			t.Errorf("chunk %d has %d tokens, want <= maxTokens (40)", i, n)
+		}
+		// Every chunk but the last must have reached minTokens before a
+		// cut point was allowed; the last chunk can be short because it's
+		// whatever's left.
+		if i < len(chunks)-1 && n < 10 {
+			t.Errorf("chunk %d has %d tokens, want >= minTokens (10)", i, n)
+		}
+	}
+}
+
+func TestCreateContentDefinedSubChunksCoversWholeText(t *testing.T) {
+	// Chunk boundaries land on token starts/ends with no overlap, so the
+	// whitespace between one chunk's last token and the next chunk's
+	// first token is dropped by design; compare token counts instead of
+	// exact concatenated text.
+	text := strings.Repeat("alpha beta gamma delta epsilon ", 100)
+	chunks := CreateContentDefinedSubChunks(text, 15, 8, 30)
+
+	total := 0
+	for _, c := range chunks {
+		total += countTokens(c.Text)
+	}
+	want := countTokens(text)
+	if total != want {
+		t.Errorf("chunks cover %d tokens total, want %d (every source token exactly once)", total, want)
+	}
+}
+
+func TestCreateContentDefinedSubChunksStableAroundLocalEdit(t *testing.T) {
+	// The whole point of content-defined chunking: editing text in the
+	// middle should only disturb the chunks immediately around the edit,
+	// not re-chunk everything downstream of it the way a fixed token
+	// window would.
+	words := make([]string, 400)
+	for i := range words {
+		words[i] = "word"
+	}
+	original := strings.Join(words, " ")
+
+	edited := make([]string, len(words))
+	copy(edited, words)
+	edited[200] = "EDITED"
+	editedText := strings.Join(edited, " ")
+
+	origChunks := CreateContentDefinedSubChunks(original, 20, 10, 40)
+	editedChunks := CreateContentDefinedSubChunks(editedText, 20, 10, 40)
+
+	// Chunks entirely before the edit point should be byte-for-byte
+	// identical in both runs.
+	editByteOffset := len(strings.Join(words[:200], " ")) + 1 // +1 for the separating space
+	matchingPrefix := 0
+	for i := 0; i < len(origChunks) && i < len(editedChunks); i++ {
+		if origChunks[i].EndCharIdx > editByteOffset {
+			break
+		}
+		if origChunks[i].Text != editedChunks[i].Text {
+			t.Fatalf("chunk %d before the edit differs: %q vs %q", i, origChunks[i].Text, editedChunks[i].Text)
+		}
+		matchingPrefix++
+	}
+	if matchingPrefix == 0 {
+		t.Fatal("expected at least one chunk entirely before the edit to match unchanged")
+	}
+}
+
+func TestCreateContentDefinedSubChunksStableDownstreamOfEdit(t *testing.T) {
+	// Unlike the repeated-"word" case above, this uses varied, realistic
+	// prose and checks stability *downstream* of the edit, not just the
+	// unaffected prefix before it — the gap the polynomial rolling hash
+	// this function used to use (reset to 0 at every cut, accumulated
+	// unboundedly since) silently failed: editing the very first "fox"
+	// changed the hash for every token after it within that first chunk,
+	// and nothing ever resynced with the unedited run afterward.
+	passage := "the quick brown fox jumps over the lazy dog while a gentle river winds beneath the old stone bridge near the quiet village square and the morning mist begins to lift slowly across the hills "
+	original := strings.Repeat(passage, 40)
+	// "fox" and "wolf" are both single tokens under tokenRegex, so this
+	// edit changes one token's content without changing the total token
+	// count anywhere in the text.
+	edited := strings.Replace(original, "fox", "wolf", 1)
+
+	origChunks := CreateContentDefinedSubChunks(original, 30, 15, 60)
+	editedChunks := CreateContentDefinedSubChunks(edited, 30, 15, 60)
+
+	if len(origChunks) < 5 || len(editedChunks) < 5 {
+		t.Fatalf("got %d/%d chunks, want several for a long repeated passage", len(origChunks), len(editedChunks))
+	}
+
+	matched := 0
+	for i := len(origChunks) - 1; i >= 0 && i < len(editedChunks); i-- {
+		if origChunks[i].Text != editedChunks[i].Text {
+			break
+		}
+		matched++
+	}
+	if matched == 0 {
+		t.Fatal("no suffix chunks matched between the original and edited runs — the rolling hash isn't forgetting the early edit")
+	}
+	if matched < len(origChunks)/2 {
+		t.Errorf("only %d/%d chunks from the end matched between runs, want most chunks downstream of a single early single-token edit to be stable", matched, len(origChunks))
+	}
+}
+
+func TestCreateContentDefinedSubChunksEmptyText(t *testing.T) {
+	if chunks := CreateContentDefinedSubChunks("", 20, 10, 40); len(chunks) != 0 {
+		t.Errorf("CreateContentDefinedSubChunks(\"\") = %d chunks, want 0", len(chunks))
+	}
+}
+
+func TestMaskForAverage(t *testing.T) {
+	cases := map[int]uint64{
+		1:   0,
+		2:   1,
+		3:   3,
+		4:   3,
+		5:   7,
+		64:  63,
+		65:  127,
+		128: 127,
+	}
+	for avg, want := range cases {
+		if got := maskForAverage(avg); got != want {
+			t.Errorf("maskForAverage(%d) = %d, want %d", avg, got, want)
+		}
+	}
+}
+
+func TestTokenFingerprintDeterministic(t *testing.T) {
+	a := tokenFingerprint("hello")
+	b := tokenFingerprint("hello")
+	if a != b {
+		t.Errorf("tokenFingerprint(%q) not deterministic: %d != %d", "hello", a, b)
+	}
+	if tokenFingerprint("hello") == tokenFingerprint("world") {
+		t.Error("tokenFingerprint(\"hello\") == tokenFingerprint(\"world\"), want distinct fingerprints")
+	}
+}
@@ -2,7 +2,6 @@ package pipeline
 
 import (
 	"archive/zip"
-	"bytes"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -16,21 +15,47 @@ import (
 // MaxFileSize - 50MB hard limit for text extraction
 const MaxFileSize = 50 * 1024 * 1024
 
-// Extractor is the interface that different file parsers must implement
-type Extractor interface {
-	Extract(path string) (string, error)
-}
-
 // ExtractDocument is the main entry point.
 // It determines the file type and calls the appropriate extractor.
 func ExtractDocument(path string) (string, error) {
+	text, _, err := ExtractDocumentWithMetadata(path)
+	return text, err
+}
+
+// ExtractDocumentWithMetadata is like ExtractDocument but also returns any
+// structured fields the extractor pulled out along the way (EXIF GPS/camera
+// fields for images, ID3 tags for audio). Text-only extractors return a nil
+// map. HandleDocAdd merges the metadata into its placeholder response's
+// Metadata; it is not written to documents.metadata. MediaEntities can
+// turn it into NER-shaped entities, but nothing in the server calls it.
+func ExtractDocumentWithMetadata(path string) (string, map[string]interface{}, error) {
+	text, metadata, _, err := extractDocumentFull(path)
+	return text, metadata, err
+}
+
+// ExtractDocumentWithSegments is like ExtractDocumentWithMetadata but also
+// returns the Segments a structure-aware extractor (PDF, DOCX) populated
+// alongside the flattened text, e.g. so a chunker could snap chunk
+// boundaries to paragraph/page breaks instead of cutting mid-sentence.
+// Extractors with no structure to offer (plain text, images, audio) return
+// a nil slice.
+//
+// Nothing downstream consumes Segments yet — there is no chunk+embed+insert
+// path wired into HandleDocAdd/HandleDocAddBatch at all (see their
+// placeholder responses) — so this is extraction only for now, not
+// persistence.
+func ExtractDocumentWithSegments(path string) (string, map[string]interface{}, []Segment, error) {
+	return extractDocumentFull(path)
+}
+
+func extractDocumentFull(path string) (string, map[string]interface{}, []Segment, error) {
 	// 1. Size Safety Check
 	info, err := os.Stat(path)
 	if err != nil {
-		return "", fmt.Errorf("file not found: %w", err)
+		return "", nil, nil, fmt.Errorf("file not found: %w", err)
 	}
 	if info.Size() > MaxFileSize {
-		return "", fmt.Errorf("file exceeds size limit of 50MB")
+		return "", nil, nil, fmt.Errorf("file exceeds size limit of 50MB")
 	}
 
 	// 2. Identify Type (Reuse your ingest package logic)
@@ -38,63 +63,153 @@ func ExtractDocument(path string) (string, error) {
 	// For simplicity, we assume the handler passed a valid path with extension.
 	fileType := ingest.GetProcessorType(path)
 
-	switch fileType {
-	case "text":
-		return extractText(path)
-	case "pdf":
-		return extractPDF(path)
-	case "word": // .docx
-		return extractDOCX(path)
-	case "rtf":
-		return "", fmt.Errorf("RTF extraction requires python fallback") // Hard in pure Go
-	case "unknown":
-		return "", fmt.Errorf("unsupported file type")
+	// 3. Dispatch to whichever Extractor(s) registered for this type, in
+	// priority order (see registry.go). RTF has no extractor registered by
+	// default (see extract_rtf.go), so it falls through to "no extractor
+	// registered for rtf" unless the binary was built with -tags rtf.
+	// "unknown" has no default extractor either, but -tags tika registers
+	// one for it: Tika sniffs content server-side, so it's the one backend
+	// that can still do something with a format ingest.GetProcessorType
+	// doesn't recognize at all.
+	result, err := extract(fileType, path)
+	if err != nil {
+		return "", nil, nil, err
 	}
+	return result.Text, result.Metadata, result.Segments, nil
+}
 
-	return "", fmt.Errorf("no extractor found for %s", fileType)
+func init() {
+	Register("text", textExtractor{})
+	Register("pdf", pdfExtractor{})
+	Register("word", docxExtractor{})
+	Register("image", imageMediaExtractor{})
+	Register("audio", audioMediaExtractor{})
 }
 
 // ---------------------------------------------------------
 // 1. PLAIN TEXT EXTRACTOR (.txt, .md)
 // ---------------------------------------------------------
-func extractText(path string) (string, error) {
+
+type textExtractor struct{}
+
+func (textExtractor) Priority() int { return 100 }
+
+func (textExtractor) Extract(path string) (ExtractResult, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
-		return "", err
+		return ExtractResult{}, err
 	}
 	// Convert to string (Go handles UTF-8 naturally)
-	return string(content), nil
+	return ExtractResult{Text: string(content)}, nil
 }
 
 // ---------------------------------------------------------
 // 2. PDF EXTRACTOR
-// Uses "github.com/dslipak/pdf"
+// Uses "github.com/dslipak/pdf", which (unlike upstream rsc.io/pdf) groups
+// a page's text runs into rows via Page.GetTextByRow(), letting us walk
+// page-by-page instead of only getting one flattened Reader.GetPlainText().
 // ---------------------------------------------------------
-func extractPDF(path string) (string, error) {
-	r, err := pdf.Open(path)
+
+// minCharsPerPage is the threshold below which pdfExtractor treats its own
+// output as untrustworthy (almost certainly a scanned page with no text
+// layer) and reports ErrLowConfidence so a registered OCR backend, if any,
+// gets a turn instead.
+const minCharsPerPage = 20
+
+type pdfExtractor struct{}
+
+// Priority is high: native text extraction is cheap and exact, so it
+// should always be tried before an OCR fallback.
+func (pdfExtractor) Priority() int { return 100 }
+
+func (pdfExtractor) Extract(path string) (ExtractResult, error) {
+	text, segments, lowConfidence, err := extractPDFSegments(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to open PDF: %w", err)
+		return ExtractResult{}, err
 	}
+	result := ExtractResult{Text: text, Segments: segments}
+	if lowConfidence {
+		return result, ErrLowConfidence
+	}
+	return result, nil
+}
 
-	var buf bytes.Buffer
-	// GetPlainReader returns a reader that outputs the text content
-	b, err := r.GetPlainText()
+// extractPDFSegments walks the PDF page by page, emitting one paragraph
+// Segment per page's text plus a page-break Segment between consecutive
+// pages (but not after the last one) so a downstream chunker can cite
+// "page 3" instead of a raw character offset into the flattened text.
+func extractPDFSegments(path string) (text string, segments []Segment, lowConfidence bool, err error) {
+	r, err := pdf.Open(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to read PDF text: %w", err)
+		return "", nil, false, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	numPages := r.NumPage()
+	var flat strings.Builder
+
+	for i := 1; i <= numPages; i++ {
+		rows, err := r.Page(i).GetTextByRow()
+		if err != nil {
+			return "", nil, false, fmt.Errorf("failed to read page %d text: %w", i, err)
+		}
+
+		var pageText strings.Builder
+		for _, row := range rows {
+			for _, run := range row.Content {
+				pageText.WriteString(run.S)
+			}
+			pageText.WriteString("\n")
+		}
+
+		pageIdx := i - 1
+		segments = append(segments, Segment{Kind: SegmentParagraph, Text: pageText.String(), PageOrParaIndex: pageIdx})
+		flat.WriteString(pageText.String())
+
+		if i < numPages {
+			segments = append(segments, Segment{Kind: SegmentPageBreak, PageOrParaIndex: pageIdx})
+			flat.WriteString("\f")
+		}
 	}
 
-	buf.ReadFrom(b)
-	return buf.String(), nil
+	text = flat.String()
+	lowConfidence = numPages > 0 && len(strings.TrimSpace(text))/numPages < minCharsPerPage
+	return text, segments, lowConfidence, nil
 }
 
 // ---------------------------------------------------------
 // 3. DOCX EXTRACTOR (Native Go / No Heavy Libs)
-// DOCX is just a ZIP file. We unzip -> find word/document.xml -> strip tags.
+// DOCX is just a ZIP file. We unzip -> find word/document.xml -> walk its
+// paragraphs, tracking w:pStyle (Heading1..6, ListParagraph) and w:tbl
+// nesting so each paragraph becomes a Segment of the right Kind.
 // ---------------------------------------------------------
-func extractDOCX(path string) (string, error) {
+
+type docxExtractor struct{}
+
+func (docxExtractor) Priority() int { return 100 }
+
+func (docxExtractor) Extract(path string) (ExtractResult, error) {
+	segments, err := extractDOCXSegments(path)
+	if err != nil {
+		return ExtractResult{}, err
+	}
+
+	var flat strings.Builder
+	for _, seg := range segments {
+		flat.WriteString(seg.Text)
+		flat.WriteString("\n")
+	}
+
+	return ExtractResult{Text: flat.String(), Segments: segments}, nil
+}
+
+// extractDOCXSegments streams word/document.xml and emits one Segment per
+// <w:p> with non-blank text (empty paragraphs are skipped and don't advance
+// PageOrParaIndex): its w:pStyle val decides heading/list-item/paragraph, and whether
+// it falls inside a <w:tbl> promotes it to table-cell regardless of style.
+func extractDOCXSegments(path string) ([]Segment, error) {
 	r, err := zip.OpenReader(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to open DOCX zip: %w", err)
+		return nil, fmt.Errorf("failed to open DOCX zip: %w", err)
 	}
 	defer r.Close()
 
@@ -108,19 +223,46 @@ func extractDOCX(path string) (string, error) {
 	}
 
 	if documentXML == nil {
-		return "", fmt.Errorf("invalid docx: missing word/document.xml")
+		return nil, fmt.Errorf("invalid docx: missing word/document.xml")
 	}
 
 	rc, err := documentXML.Open()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer rc.Close()
 
 	// Parse XML and extract text
 	// We use a stream decoder to be memory efficient
 	decoder := xml.NewDecoder(rc)
-	var textBuilder strings.Builder
+	var segments []Segment
+	var para strings.Builder
+	var pStyle string
+	tableDepth := 0
+	paraIdx := 0
+
+	flushPara := func() {
+		text := strings.TrimSpace(para.String())
+		para.Reset()
+		if text == "" {
+			return
+		}
+
+		kind := SegmentParagraph
+		styleHint := ""
+		switch {
+		case tableDepth > 0:
+			kind = SegmentTableCell
+		case strings.HasPrefix(pStyle, "Heading"):
+			kind = SegmentHeading
+			styleHint = pStyle
+		case pStyle == "ListParagraph":
+			kind = SegmentListItem
+		}
+
+		segments = append(segments, Segment{Kind: kind, Text: text, PageOrParaIndex: paraIdx, StyleHint: styleHint})
+		paraIdx++
+	}
 
 	for {
 		token, err := decoder.Token()
@@ -128,24 +270,72 @@ func extractDOCX(path string) (string, error) {
 			break
 		}
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		switch t := token.(type) {
 		case xml.StartElement:
-			// <w:p> indicates a paragraph break in Word
-			if t.Name.Local == "p" {
-				textBuilder.WriteString("\n")
+			switch t.Name.Local {
+			case "tbl":
+				tableDepth++
+			case "p":
+				// <w:p> indicates a paragraph break in Word; reset the
+				// style tracked for the paragraph we're about to read.
+				pStyle = ""
+			case "pStyle":
+				for _, a := range t.Attr {
+					if a.Name.Local == "val" {
+						pStyle = a.Value
+					}
+				}
+			case "tab":
+				// <w:tab/> indicates a tab
+				para.WriteString("\t")
 			}
-			// <w:tab/> indicates a tab
-			if t.Name.Local == "tab" {
-				textBuilder.WriteString("\t")
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				flushPara()
+			case "tbl":
+				if tableDepth > 0 {
+					tableDepth--
+				}
 			}
 		case xml.CharData:
 			// The actual text content
-			textBuilder.Write(t)
+			para.Write(t)
 		}
 	}
+	flushPara()
 
-	return textBuilder.String(), nil
+	return segments, nil
+}
+
+// ---------------------------------------------------------
+// 4. IMAGE / AUDIO EXTRACTORS
+// Thin Extractor wrappers around extractImage/extractAudio (media.go).
+// ---------------------------------------------------------
+
+type imageMediaExtractor struct{}
+
+func (imageMediaExtractor) Priority() int { return 100 }
+
+func (imageMediaExtractor) Extract(path string) (ExtractResult, error) {
+	text, meta, err := extractImage(path)
+	if err != nil {
+		return ExtractResult{}, err
+	}
+	return ExtractResult{Text: text, Metadata: meta}, nil
+}
+
+type audioMediaExtractor struct{}
+
+func (audioMediaExtractor) Priority() int { return 100 }
+
+func (audioMediaExtractor) Extract(path string) (ExtractResult, error) {
+	text, meta, err := extractAudio(path)
+	if err != nil {
+		return ExtractResult{}, err
+	}
+	return ExtractResult{Text: text, Metadata: meta}, nil
 }
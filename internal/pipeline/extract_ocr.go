@@ -0,0 +1,60 @@
+//go:build ocr
+
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	// Lower priority than pdfExtractor (100): native text extraction runs
+	// first, and this only gets a turn when that comes back ErrLowConfidence
+	// (a scanned PDF with no text layer).
+	Register("pdf", ocrPDFExtractor{})
+}
+
+// ocrPDFExtractor rasterizes each page with pdftoppm and reads it back with
+// Tesseract. Both are external binaries (poppler-utils + tesseract-ocr)
+// rather than Go libraries, same tradeoff the Python worker already makes
+// for model-heavy work: shell out to the tool built for the job instead of
+// reimplementing it.
+type ocrPDFExtractor struct{}
+
+func (ocrPDFExtractor) Priority() int { return 50 }
+
+func (ocrPDFExtractor) Extract(path string) (ExtractResult, error) {
+	tmpDir, err := os.MkdirTemp("", "rag-ocr-*")
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("failed to create OCR temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	pagePrefix := filepath.Join(tmpDir, "page")
+	if out, err := exec.Command("pdftoppm", "-png", "-r", "300", path, pagePrefix).CombinedOutput(); err != nil {
+		return ExtractResult{}, fmt.Errorf("pdftoppm failed: %w (%s)", err, strings.TrimSpace(string(out)))
+	}
+
+	pages, err := filepath.Glob(pagePrefix + "*.png")
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("failed to list rasterized pages: %w", err)
+	}
+	if len(pages) == 0 {
+		return ExtractResult{}, fmt.Errorf("pdftoppm produced no pages for %s", path)
+	}
+
+	var sb strings.Builder
+	for _, page := range pages {
+		out, err := exec.Command("tesseract", page, "stdout").CombinedOutput()
+		if err != nil {
+			return ExtractResult{}, fmt.Errorf("tesseract failed on %s: %w (%s)", filepath.Base(page), err, strings.TrimSpace(string(out)))
+		}
+		sb.Write(out)
+		sb.WriteString("\n")
+	}
+
+	return ExtractResult{Text: sb.String()}, nil
+}
@@ -0,0 +1,37 @@
+//go:build rtf
+
+package pipeline
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func init() {
+	Register("rtf", unrtfExtractor{})
+}
+
+// unrtfExtractor shells out to the `unrtf` binary to close the "RTF
+// extraction requires python fallback" gap without pulling in a Go RTF
+// parser of our own. Same shell-out tradeoff as ocrPDFExtractor: a
+// well-tested external tool beats reimplementing RTF's control-word syntax.
+type unrtfExtractor struct{}
+
+func (unrtfExtractor) Priority() int { return 100 }
+
+func (unrtfExtractor) Extract(path string) (ExtractResult, error) {
+	out, err := exec.Command("unrtf", "--text", path).CombinedOutput()
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("unrtf failed: %w (%s)", err, strings.TrimSpace(string(out)))
+	}
+
+	text := out
+	// unrtf --text prefixes its output with a comment banner naming itself;
+	// strip it so downstream chunking doesn't swallow it as document text.
+	if idx := strings.Index(string(text), "-----------------\n"); idx != -1 {
+		text = text[idx+len("-----------------\n"):]
+	}
+
+	return ExtractResult{Text: string(text)}, nil
+}
@@ -0,0 +1,76 @@
+//go:build tika
+
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// tikaServerURL points at a running Apache Tika server (`java -jar
+// tika-server.jar`). Overridable so deployments don't have to rebuild to
+// point at a different host; defaults to the conventional local port.
+var tikaServerURL = envOr("TIKA_SERVER_URL", "http://localhost:9998")
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func init() {
+	// Registered under "unknown": Tika sniffs content server-side from the
+	// bytes it's handed, so it's the one backend that can still do
+	// something with a format ingest.GetProcessorType doesn't recognize
+	// (e.g. .odt, .pptx, .epub) instead of every other extractor bailing.
+	Register("unknown", tikaExtractor{})
+}
+
+// tikaExtractor shells the file out to a Tika server's /tika endpoint,
+// which returns plain text for whatever format it detects. It never
+// competes with the pure-Go backends for types we already handle natively
+// (only "unknown" routes here), so Priority doesn't matter beyond being
+// the default.
+type tikaExtractor struct{}
+
+func (tikaExtractor) Priority() int { return 100 }
+
+func (tikaExtractor) Extract(path string) (ExtractResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	req, err := http.NewRequest(http.MethodPut, tikaServerURL+"/tika", f)
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("failed to build Tika request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain")
+	if ct := mime.TypeByExtension(filepath.Ext(path)); ct != "" {
+		req.Header.Set("Content-Type", ct)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("tika server request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("failed to read tika response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ExtractResult{}, fmt.Errorf("tika server returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	return ExtractResult{Text: string(body)}, nil
+}
@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ==========================================
+// BATCH JOB TRACKING
+// ==========================================
+
+// Job tracks the live state of a single /docs/batch run so HTTP handlers
+// can poll progress or cancel in-flight work without touching the
+// goroutine doing the actual extraction. SSE fan-out to subscribers is the
+// caller's responsibility (see api/sse.Broker); Job only owns the
+// counters and cancellation.
+type Job struct {
+	BatchID string
+
+	total     int32
+	processed int32
+	failed    int32
+
+	Ctx    context.Context
+	Cancel context.CancelFunc
+
+	mu          sync.Mutex
+	status      string // "processing", "completed", "failed", "cancelled"
+	currentFile string
+	failures    []string
+}
+
+// NewJob creates a job in the "processing" state with a cancellable context
+// derived from parent.
+func NewJob(parent context.Context, batchID string, total int) *Job {
+	ctx, cancel := context.WithCancel(parent)
+	return &Job{
+		BatchID: batchID,
+		total:   int32(total),
+		Ctx:     ctx,
+		Cancel:  cancel,
+		status:  "processing",
+	}
+}
+
+// MarkFileStarted records which file is currently being processed.
+func (j *Job) MarkFileStarted(file string) {
+	j.mu.Lock()
+	j.currentFile = file
+	j.mu.Unlock()
+}
+
+// MarkFileDone increments processed/failed counters.
+func (j *Job) MarkFileDone(file string, err error) {
+	if err != nil {
+		atomic.AddInt32(&j.failed, 1)
+		j.mu.Lock()
+		j.failures = append(j.failures, file+": "+err.Error())
+		j.mu.Unlock()
+	}
+	atomic.AddInt32(&j.processed, 1)
+}
+
+// Finish marks the job terminal.
+func (j *Job) Finish(status string) {
+	j.mu.Lock()
+	j.status = status
+	j.mu.Unlock()
+}
+
+// Snapshot is a point-in-time read of a job's counters for polling endpoints.
+type Snapshot struct {
+	Status      string
+	Total       int
+	Processed   int
+	Failed      int
+	Pct         float32
+	CurrentFile string
+	Failures    []string
+}
+
+// Snapshot returns the job's current state.
+func (j *Job) Snapshot() Snapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	processed := atomic.LoadInt32(&j.processed)
+	s := Snapshot{
+		Status:      j.status,
+		Total:       int(j.total),
+		Processed:   int(processed),
+		Failed:      int(atomic.LoadInt32(&j.failed)),
+		CurrentFile: j.currentFile,
+		Failures:    j.failures,
+	}
+	if s.Total > 0 {
+		s.Pct = 100 * float32(processed) / float32(s.Total)
+	}
+	return s
+}
+
+// JobManager tracks all in-flight and recently finished batch jobs, keyed
+// by batchID. It is safe for concurrent use.
+type JobManager struct {
+	jobs sync.Map // batchID -> *Job
+}
+
+// NewJobManager creates an empty manager.
+func NewJobManager() *JobManager {
+	return &JobManager{}
+}
+
+// Create registers a new job for batchID and returns it.
+func (m *JobManager) Create(parent context.Context, batchID string, total int) *Job {
+	job := NewJob(parent, batchID, total)
+	m.jobs.Store(batchID, job)
+	return job
+}
+
+// Get looks up a job by batchID.
+func (m *JobManager) Get(batchID string) (*Job, bool) {
+	v, ok := m.jobs.Load(batchID)
+	if !ok {
+		return nil, false
+	}
+	return v.(*Job), true
+}
+
+// Delete removes a job's bookkeeping once a caller no longer needs it.
+func (m *JobManager) Delete(batchID string) {
+	m.jobs.Delete(batchID)
+}
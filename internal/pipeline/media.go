@@ -0,0 +1,451 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+// ---------------------------------------------------------
+// 4. IMAGE EXTRACTOR (.jpg, .jpeg, .png, .gif, .heic, .heif)
+// Builds a synthetic text body from EXIF fields so photos become
+// semantically searchable ("photos taken in Berlin in 2023") through the
+// same chunk+embed+NER path as every other document type.
+// ---------------------------------------------------------
+
+// exifTag numbers we care about, per the TIFF 6.0 / Exif 2.3 spec.
+const (
+	tagMake            = 0x010F
+	tagModel           = 0x0110
+	tagOrientation     = 0x0112
+	tagExifIFDPointer  = 0x8769
+	tagGPSIFDPointer   = 0x8825
+	tagDateTimeOrig    = 0x9003
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+)
+
+func extractImage(path string) (string, map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Only JPEG carries EXIF in the APP1 segment we parse below. PNG/GIF/HEIF
+	// don't store the same TIFF-based metadata, so we fall back to a bare
+	// "image file" synthetic description rather than failing the upload.
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return fmt.Sprintf("Image file (%s).", strings.ToUpper(strings.TrimPrefix(path[strings.LastIndex(path, ".")+1:], "."))), nil, nil
+	}
+
+	fields, err := parseJPEGExif(data)
+	if err != nil || len(fields) == 0 {
+		return "Photo (no EXIF metadata found).", nil, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Photo")
+	if camMake, ok := fields["camera_make"]; ok {
+		sb.WriteString(" taken with " + camMake)
+		if model, ok := fields["camera_model"]; ok {
+			sb.WriteString(" " + model)
+		}
+	} else if model, ok := fields["camera_model"]; ok {
+		sb.WriteString(" taken with " + model)
+	}
+	if ts, ok := fields["timestamp"]; ok {
+		sb.WriteString(" on " + ts)
+	}
+	if lat, ok := fields["gps_lat"]; ok {
+		if lon, ok := fields["gps_lon"]; ok {
+			sb.WriteString(fmt.Sprintf(". Location: %s, %s", lat, lon))
+		}
+	}
+	sb.WriteString(".")
+
+	meta := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		meta[k] = v
+	}
+	return sb.String(), meta, nil
+}
+
+// parseJPEGExif walks a JPEG's segment table looking for the APP1 "Exif\0\0"
+// segment, then reads the TIFF-structured IFD0 (+ the Exif and GPS sub-IFDs)
+// it contains. Returns a flat string-keyed map; unrecognized/malformed tags
+// are skipped rather than failing the whole extraction.
+func parseJPEGExif(data []byte) (map[string]string, error) {
+	pos := 2 // skip SOI
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("malformed JPEG segment at offset %d", pos)
+		}
+		marker := data[pos+1]
+		if marker == 0xD8 || marker == 0xD9 { // SOI/EOI, no length field
+			pos += 2
+			continue
+		}
+		segLen := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		segStart := pos + 4
+		segEnd := pos + 2 + segLen
+		if segEnd > len(data) {
+			break
+		}
+
+		if marker == 0xE1 && segEnd-segStart >= 6 && string(data[segStart:segStart+6]) == "Exif\x00\x00" {
+			return readTIFF(data[segStart+6 : segEnd])
+		}
+		if marker == 0xDA { // Start of Scan: image data follows, EXIF never appears after this
+			break
+		}
+		pos = segEnd
+	}
+	return nil, fmt.Errorf("no EXIF APP1 segment found")
+}
+
+// readTIFF parses a TIFF-format byte slice (the body of an Exif APP1
+// segment) and returns the fields we extract, following IFD0 -> Exif
+// SubIFD -> GPS IFD pointers as needed.
+func readTIFF(tiff []byte) (map[string]string, error) {
+	if len(tiff) < 8 {
+		return nil, fmt.Errorf("TIFF header too short")
+	}
+
+	var bo binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid TIFF byte order marker")
+	}
+
+	ifd0Offset := bo.Uint32(tiff[4:8])
+	fields := make(map[string]string)
+
+	ifd0, err := readIFD(tiff, bo, ifd0Offset)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := ifd0[tagMake]; ok {
+		fields["camera_make"] = strings.TrimRight(v.ascii(tiff, bo), "\x00")
+	}
+	if v, ok := ifd0[tagModel]; ok {
+		fields["camera_model"] = strings.TrimRight(v.ascii(tiff, bo), "\x00")
+	}
+	if v, ok := ifd0[tagOrientation]; ok {
+		fields["orientation"] = strconv.Itoa(int(v.short(bo)))
+	}
+
+	if v, ok := ifd0[tagExifIFDPointer]; ok {
+		exifIFD, err := readIFD(tiff, bo, v.long(bo))
+		if err == nil {
+			if dt, ok := exifIFD[tagDateTimeOrig]; ok {
+				fields["timestamp"] = strings.TrimRight(dt.ascii(tiff, bo), "\x00")
+			}
+		}
+	}
+
+	if v, ok := ifd0[tagGPSIFDPointer]; ok {
+		gpsIFD, err := readIFD(tiff, bo, v.long(bo))
+		if err == nil {
+			lat, latOK := gpsCoord(gpsIFD, tiff, bo, tagGPSLatitude, tagGPSLatitudeRef)
+			lon, lonOK := gpsCoord(gpsIFD, tiff, bo, tagGPSLongitude, tagGPSLongitudeRef)
+			if latOK && lonOK {
+				fields["gps_lat"] = strconv.FormatFloat(lat, 'f', 6, 64)
+				fields["gps_lon"] = strconv.FormatFloat(lon, 'f', 6, 64)
+			}
+		}
+	}
+
+	return fields, nil
+}
+
+// ifdEntry is a raw 12-byte IFD directory entry: tag, type, count, and a
+// 4-byte value/offset field whose meaning depends on type+count.
+type ifdEntry struct {
+	typ    uint16
+	count  uint32
+	offset [4]byte
+}
+
+func (e ifdEntry) long(bo binary.ByteOrder) uint32 {
+	return bo.Uint32(e.offset[:])
+}
+
+func (e ifdEntry) short(bo binary.ByteOrder) uint16 {
+	return bo.Uint16(e.offset[:2])
+}
+
+// ascii resolves an ASCII-type entry to its string value, dereferencing the
+// offset into tiff if the value doesn't fit inline (count > 4 bytes).
+func (e ifdEntry) ascii(tiff []byte, bo binary.ByteOrder) string {
+	if e.count <= 4 {
+		return string(e.offset[:e.count])
+	}
+	off := bo.Uint32(e.offset[:])
+	if int(off)+int(e.count) > len(tiff) {
+		return ""
+	}
+	return string(tiff[off : off+e.count])
+}
+
+// readIFD reads a single Image File Directory at byte offset off within
+// tiff, returning its entries keyed by tag number.
+func readIFD(tiff []byte, bo binary.ByteOrder, off uint32) (map[uint16]ifdEntry, error) {
+	if int(off)+2 > len(tiff) {
+		return nil, fmt.Errorf("IFD offset out of range")
+	}
+	count := bo.Uint16(tiff[off : off+2])
+	entries := make(map[uint16]ifdEntry, count)
+
+	base := int(off) + 2
+	for i := 0; i < int(count); i++ {
+		entryOff := base + i*12
+		if entryOff+12 > len(tiff) {
+			break
+		}
+		tag := bo.Uint16(tiff[entryOff : entryOff+2])
+		typ := bo.Uint16(tiff[entryOff+2 : entryOff+4])
+		cnt := bo.Uint32(tiff[entryOff+4 : entryOff+8])
+		var valOff [4]byte
+		copy(valOff[:], tiff[entryOff+8:entryOff+12])
+		entries[tag] = ifdEntry{typ: typ, count: cnt, offset: valOff}
+	}
+	return entries, nil
+}
+
+// gpsCoord resolves a GPS coordinate from its 3-rational (degrees, minutes,
+// seconds) entry and applies the sign from its N/S or E/W ref byte.
+func gpsCoord(ifd map[uint16]ifdEntry, tiff []byte, bo binary.ByteOrder, coordTag, refTag uint16) (float64, bool) {
+	coord, ok := ifd[coordTag]
+	if !ok || coord.count != 3 {
+		return 0, false
+	}
+	off := bo.Uint32(coord.offset[:])
+	if int(off)+24 > len(tiff) {
+		return 0, false
+	}
+
+	rational := func(i int) float64 {
+		num := bo.Uint32(tiff[int(off)+i*8 : int(off)+i*8+4])
+		den := bo.Uint32(tiff[int(off)+i*8+4 : int(off)+i*8+8])
+		if den == 0 {
+			return 0
+		}
+		return float64(num) / float64(den)
+	}
+
+	deg, min, sec := rational(0), rational(1), rational(2)
+	value := deg + min/60 + sec/3600
+
+	if ref, ok := ifd[refTag]; ok {
+		refChar := string(ref.offset[:1])
+		if refChar == "S" || refChar == "W" {
+			value = -value
+		}
+	}
+	return value, true
+}
+
+// ---------------------------------------------------------
+// 5. AUDIO EXTRACTOR (.mp3, .flac, .ogg, .m4a)
+// Reads ID3v2 text frames (the only tag format we parse natively) and
+// builds a synthetic "text" body so songs become searchable by title,
+// artist, album, etc. Formats without an ID3v2 header return a minimal
+// placeholder rather than failing the upload.
+// ---------------------------------------------------------
+
+var id3FrameFields = map[string]string{
+	"TIT2": "audio_title",
+	"TPE1": "audio_artist",
+	"TALB": "audio_album",
+	"TYER": "audio_year",
+	"TDRC": "audio_year",
+	"TCON": "audio_genre",
+}
+
+func extractAudio(path string) (string, map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(data) < 10 || string(data[0:3]) != "ID3" {
+		return "Audio file (no ID3v2 tag found).", nil, nil
+	}
+
+	fields, err := parseID3v2(data)
+	if err != nil || len(fields) == 0 {
+		return "Audio file (no ID3v2 tag found).", nil, nil
+	}
+
+	var sb strings.Builder
+	if title, ok := fields["audio_title"]; ok {
+		sb.WriteString("Song '" + title + "'")
+	} else {
+		sb.WriteString("Untitled track")
+	}
+	if artist, ok := fields["audio_artist"]; ok {
+		sb.WriteString(" by " + artist)
+	}
+	if album, ok := fields["audio_album"]; ok {
+		sb.WriteString(" from the album " + album)
+	}
+	if year, ok := fields["audio_year"]; ok {
+		sb.WriteString(" (" + year + ")")
+	}
+	if genre, ok := fields["audio_genre"]; ok {
+		sb.WriteString(", genre " + genre)
+	}
+	sb.WriteString(".")
+
+	meta := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		meta[k] = v
+	}
+	return sb.String(), meta, nil
+}
+
+// parseID3v2 reads the frames out of an ID3v2.3/2.4 tag, decoding the
+// text-information frames we map in id3FrameFields. ID3v2.2's 3-character
+// frame IDs and 6-byte frame headers aren't parsed, so a v2.2 tag yields
+// no fields. Frame sizes are
+// syncsafe (7 bits per byte) from v2.4 onward; we accept the plain 4-byte
+// big-endian form too since most v2.3 encoders in the wild use it.
+func parseID3v2(data []byte) (map[string]string, error) {
+	if len(data) < 10 {
+		return nil, fmt.Errorf("ID3 header too short")
+	}
+	version := data[3]
+	tagSize := syncsafeUint32(data[6:10])
+	if 10+int(tagSize) > len(data) {
+		return nil, fmt.Errorf("ID3 tag size out of range")
+	}
+
+	fields := make(map[string]string)
+	pos := 10
+	end := 10 + int(tagSize)
+
+	for pos+10 <= end {
+		frameID := string(data[pos : pos+4])
+		if frameID == "\x00\x00\x00\x00" {
+			break // padding
+		}
+
+		var frameSize uint32
+		if version >= 4 {
+			frameSize = syncsafeUint32(data[pos+4 : pos+8])
+		} else {
+			frameSize = binary.BigEndian.Uint32(data[pos+4 : pos+8])
+		}
+
+		contentStart := pos + 10
+		contentEnd := contentStart + int(frameSize)
+		if contentEnd > end || contentEnd < contentStart {
+			break
+		}
+
+		if key, ok := id3FrameFields[frameID]; ok {
+			if text := decodeID3Text(data[contentStart:contentEnd]); text != "" {
+				fields[key] = text
+			}
+		}
+
+		pos = contentEnd
+	}
+	return fields, nil
+}
+
+// syncsafeUint32 decodes a 4-byte ID3v2 syncsafe integer (7 significant
+// bits per byte, MSB of each byte always 0).
+func syncsafeUint32(b []byte) uint32 {
+	return uint32(b[0])<<21 | uint32(b[1])<<14 | uint32(b[2])<<7 | uint32(b[3])
+}
+
+// decodeID3Text strips the leading text-encoding byte and trailing NULs
+// from an ID3v2 text frame body, decoding UTF-16 when that's the declared
+// encoding.
+func decodeID3Text(body []byte) string {
+	if len(body) < 1 {
+		return ""
+	}
+	encoding := body[0]
+	text := body[1:]
+
+	switch encoding {
+	case 0x00, 0x03: // ISO-8859-1 or UTF-8
+		return strings.Trim(string(text), "\x00")
+	case 0x01, 0x02: // UTF-16 with/without BOM
+		if len(text) < 2 {
+			return ""
+		}
+		var bo binary.ByteOrder = binary.LittleEndian
+		start := 0
+		if text[0] == 0xFE && text[1] == 0xFF {
+			bo = binary.BigEndian
+			start = 2
+		} else if text[0] == 0xFF && text[1] == 0xFE {
+			start = 2
+		}
+		units := make([]uint16, 0, (len(text)-start)/2)
+		for i := start; i+1 < len(text); i += 2 {
+			units = append(units, bo.Uint16(text[i:i+2]))
+		}
+		return strings.Trim(string(utf16.Decode(units)), "\x00")
+	default:
+		return strings.Trim(string(text), "\x00")
+	}
+}
+
+// MediaEntities converts the structured fields ExtractDocumentWithMetadata
+// returns for images/audio into synthetic NER-shaped entities (PHOTO_GPS,
+// AUDIO_ARTIST, etc.) so the knowledge graph gains a media dimension
+// without waiting on the NER worker to recognize them. Callers pass the
+// same synthetic text used for chunking so Start/End offsets line up.
+func MediaEntities(text string, meta map[string]interface{}) []models.WorkerNEREntity {
+	labelFor := map[string]string{
+		"camera_make":  "CAMERA_MAKE",
+		"camera_model": "CAMERA_MODEL",
+		"timestamp":    "PHOTO_DATE",
+		"gps_lat":      "PHOTO_GPS",
+		"gps_lon":      "PHOTO_GPS",
+		"audio_title":  "AUDIO_TITLE",
+		"audio_artist": "AUDIO_ARTIST",
+		"audio_album":  "AUDIO_ALBUM",
+		"audio_year":   "AUDIO_YEAR",
+		"audio_genre":  "AUDIO_GENRE",
+	}
+
+	var entities []models.WorkerNEREntity
+	for key, label := range labelFor {
+		raw, ok := meta[key]
+		if !ok {
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok || value == "" {
+			continue
+		}
+		idx := strings.Index(text, value)
+		if idx < 0 {
+			continue
+		}
+		entities = append(entities, models.WorkerNEREntity{
+			Text:  value,
+			Label: label,
+			Start: idx,
+			End:   idx + len(value),
+			Score: 1.0, // structured field, not a model prediction
+		})
+	}
+	return entities
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+// reassemble concatenates every chunk's Text back together with the
+// overlap removed, to sanity-check that chunks cover text front-to-back
+// without gaps.
+func reassembleNoOverlap(t *testing.T, text string, chunks []SubChunk) string {
+	t.Helper()
+	var sb strings.Builder
+	for i, c := range chunks {
+		start := c.StartCharIdx
+		if i > 0 && start < chunks[i-1].EndCharIdx {
+			start = chunks[i-1].EndCharIdx
+		}
+		if start > c.EndCharIdx {
+			continue
+		}
+		sb.WriteString(text[start:c.EndCharIdx])
+	}
+	return sb.String()
+}
+
+func TestCreateRecursiveSubChunksRespectsParagraphs(t *testing.T) {
+	text := strings.Repeat("first paragraph word ", 60) + "\n\n" + strings.Repeat("second paragraph word ", 60)
+	chunks := CreateRecursiveSubChunks(text, 50, 0)
+
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want at least 2 for text split across a paragraph break", len(chunks))
+	}
+	for _, c := range chunks {
+		if countTokens(c.Text) > 50 {
+			t.Errorf("chunk exceeds maxTokens: %d tokens in %q", countTokens(c.Text), c.Text)
+		}
+	}
+}
+
+func TestCreateRecursiveSubChunksCoversWholeText(t *testing.T) {
+	text := strings.Repeat("alpha beta gamma delta. ", 40)
+	chunks := CreateRecursiveSubChunks(text, 30, 5)
+
+	got := reassembleNoOverlap(t, text, chunks)
+	want := text
+	if got != want {
+		t.Errorf("reassembled chunks don't reproduce source text.\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestCreateRecursiveSubChunksFallsBackWhenNoSeparators(t *testing.T) {
+	// tokenRegex matches a run of word characters as a single token, so a
+	// long run of punctuation (each character its own token, per \S) is
+	// what actually produces many tokens with none of recursiveSeparators
+	// present. splitRecursive must exhaust the separator hierarchy and
+	// fall through to CreateSubChunks' token windowing instead of
+	// returning one oversized fragment.
+	text := strings.Repeat("!", 2000)
+	chunks := CreateRecursiveSubChunks(text, 10, 0)
+
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several for an unsplittable run of 2000 single-char tokens", len(chunks))
+	}
+}
+
+func TestCreateRecursiveSubChunksEmptyText(t *testing.T) {
+	if chunks := CreateRecursiveSubChunks("", 50, 10); len(chunks) != 0 {
+		t.Errorf("CreateRecursiveSubChunks(\"\") = %d chunks, want 0", len(chunks))
+	}
+}
+
+func TestSplitOnSeparatorKeepsSeparatorAttached(t *testing.T) {
+	parts := splitOnSeparator("a\n\nb\n\nc", "\n\n")
+	want := []string{"a\n\n", "b\n\n", "c"}
+	if len(parts) != len(want) {
+		t.Fatalf("splitOnSeparator returned %d parts, want %d: %v", len(parts), len(want), parts)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %q, want %q", i, parts[i], want[i])
+		}
+	}
+
+	joined := strings.Join(parts, "")
+	if joined != "a\n\nb\n\nc" {
+		t.Errorf("joined parts = %q, want original string back", joined)
+	}
+}
+
+func TestSplitOnSeparatorNoMatch(t *testing.T) {
+	parts := splitOnSeparator("no separator here", "\n\n")
+	if len(parts) != 1 || parts[0] != "no separator here" {
+		t.Errorf("splitOnSeparator with no match = %v, want the original string unchanged", parts)
+	}
+}
@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ExtractResult is what an Extractor produces for a file: plain text plus
+// any structured fields it pulled out along the way (EXIF/ID3 tags, etc).
+// Extractors with nothing structured to report leave Metadata nil.
+// Extractors that can see document structure (DOCX headings/lists/tables,
+// PDF page boundaries) also populate Segments; extractors that can't leave
+// it nil and Text remains the only thing downstream code can rely on.
+type ExtractResult struct {
+	Text     string
+	Metadata map[string]interface{}
+	Segments []Segment
+}
+
+// Extractor is the interface file-type backends implement to register
+// themselves with the package registry, normally from their own init().
+// Priority breaks ties when more than one Extractor is registered for the
+// same file type (e.g. native PDF text vs. an OCR fallback); the
+// highest-Priority extractor runs first.
+type Extractor interface {
+	Extract(path string) (ExtractResult, error)
+	Priority() int
+}
+
+// ErrLowConfidence lets an Extractor report a result it doesn't trust (e.g.
+// a scanned PDF where native text extraction comes back nearly empty)
+// without failing the pipeline outright: the registry tries the
+// next-priority Extractor registered for the same file type, only falling
+// back to the low-confidence result if nothing else succeeds.
+var ErrLowConfidence = errors.New("pipeline: low-confidence extraction result")
+
+var (
+	registryMu     sync.Mutex
+	registryByType = make(map[string][]Extractor)
+)
+
+// Register adds e as a candidate Extractor for fileType (one of the
+// strings ingest.GetProcessorType returns, e.g. "pdf", "word", "image").
+// Backends gated behind a build tag only call this from an init() that's
+// compiled in, so registryByType reflects what the running binary can
+// actually do.
+func Register(fileType string, e Extractor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registryByType[fileType] = append(registryByType[fileType], e)
+	sort.SliceStable(registryByType[fileType], func(i, j int) bool {
+		return registryByType[fileType][i].Priority() > registryByType[fileType][j].Priority()
+	})
+}
+
+// Capabilities reports every file type with at least one registered
+// Extractor, sorted for stable output. HandleDBInfo uses this to advertise
+// which formats the running binary supports, since build tags mean that
+// varies release to release.
+func Capabilities() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	types := make([]string, 0, len(registryByType))
+	for t := range registryByType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// extract runs every Extractor registered for fileType in priority order,
+// falling back to the next one when a higher-priority extractor declines
+// via ErrLowConfidence instead of failing outright. If every candidate
+// declines, the highest-priority low-confidence result is returned rather
+// than nothing; a "real" error from one extractor doesn't stop the rest
+// from being tried.
+func extract(fileType, path string) (ExtractResult, error) {
+	registryMu.Lock()
+	candidates := append([]Extractor(nil), registryByType[fileType]...)
+	registryMu.Unlock()
+
+	if len(candidates) == 0 {
+		return ExtractResult{}, fmt.Errorf("no extractor registered for %s", fileType)
+	}
+
+	var fallback *ExtractResult
+	var lastErr error
+	for _, e := range candidates {
+		result, err := e.Extract(path)
+		if err == nil {
+			return result, nil
+		}
+		if errors.Is(err, ErrLowConfidence) {
+			if fallback == nil {
+				fallback = &result
+			}
+			continue
+		}
+		lastErr = err
+	}
+
+	if fallback != nil {
+		return *fallback, nil
+	}
+	return ExtractResult{}, lastErr
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+// SegmentKind labels what kind of document structure a Segment came from,
+// so a downstream chunker can treat them differently (e.g. never split a
+// heading away from the paragraph that follows it).
+type SegmentKind string
+
+const (
+	SegmentHeading   SegmentKind = "heading"
+	SegmentParagraph SegmentKind = "paragraph"
+	SegmentListItem  SegmentKind = "list-item"
+	SegmentTableCell SegmentKind = "table-cell"
+	SegmentPageBreak SegmentKind = "page-break"
+)
+
+// Segment is one semantically-meaningful span of extracted text: a
+// heading, paragraph, list item, table cell, or a page-break marker with
+// no text of its own. Extractors that can see document structure (DOCX
+// styles, PDF page boundaries) populate these alongside the flattened
+// ExtractResult.Text, so a downstream chunker can eventually split on real
+// boundaries instead of a whitespace-blind token window. Extractors with
+// no structure to offer (plain text, images, audio) leave this nil.
+//
+// Reachable today via pipeline.ExtractDocumentWithSegments, but nothing
+// consumes it yet — see the TODO in HandleDocAdd.
+type Segment struct {
+	Kind SegmentKind
+	Text string
+
+	// PageOrParaIndex is 0-based: the page number for PDF segments, the
+	// paragraph index for DOCX segments.
+	PageOrParaIndex int
+
+	// StyleHint carries the source style name where one exists, e.g.
+	// "Heading1".."Heading6" for a DOCX SegmentHeading. Empty otherwise.
+	StyleHint string
+}
@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func drainStream(t *testing.T, out <-chan SubChunk, errc <-chan error) ([]SubChunk, error) {
+	t.Helper()
+	var chunks []SubChunk
+	var streamErr error
+	for out != nil || errc != nil {
+		select {
+		case sc, ok := <-out:
+			if !ok {
+				out = nil
+				continue
+			}
+			chunks = append(chunks, sc)
+		case err, ok := <-errc:
+			if !ok {
+				errc = nil
+				continue
+			}
+			streamErr = err
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out draining StreamSubChunks output")
+		}
+	}
+	return chunks, streamErr
+}
+
+func TestStreamSubChunksMatchesCreateSubChunks(t *testing.T) {
+	text := strings.Repeat("the quick brown fox jumps over the lazy dog. ", 80)
+
+	want := CreateSubChunks(text, RegexTokenCounter{}, nil, 40, 10)
+
+	out, errc := StreamSubChunks(context.Background(), strings.NewReader(text), 40, 10)
+	got, err := drainStream(t, out, errc)
+	if err != nil {
+		t.Fatalf("StreamSubChunks returned error: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("StreamSubChunks produced %d chunks, want %d (matching CreateSubChunks)", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Text != want[i].Text {
+			t.Errorf("chunk %d text = %q, want %q", i, got[i].Text, want[i].Text)
+		}
+	}
+}
+
+func TestStreamSubChunksSmallReads(t *testing.T) {
+	// A reader that only ever returns a few bytes per Read exercises the
+	// buf-refill loop much harder than one big Read would.
+	text := strings.Repeat("alpha beta gamma delta ", 50)
+	r := &slowReader{data: []byte(text), step: 3}
+
+	out, errc := StreamSubChunks(context.Background(), r, 20, 5)
+	chunks, err := drainStream(t, out, errc)
+	if err != nil {
+		t.Fatalf("StreamSubChunks returned error: %v", err)
+	}
+	if len(chunks) == 0 {
+		t.Fatal("got 0 chunks from a non-empty reader")
+	}
+
+	var sb strings.Builder
+	for _, c := range chunks {
+		sb.WriteString(c.Text)
+	}
+	if !strings.Contains(text, chunks[0].Text) {
+		t.Errorf("first chunk %q isn't a substring of the source text", chunks[0].Text)
+	}
+}
+
+func TestStreamSubChunksRespectsContextCancellation(t *testing.T) {
+	text := strings.Repeat("word ", 10000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out, errc := StreamSubChunks(ctx, strings.NewReader(text), 40, 10)
+	_, err := drainStream(t, out, errc)
+	if err == nil {
+		t.Error("StreamSubChunks with an already-cancelled context returned no error")
+	}
+}
+
+func TestStreamSubChunksEmptyReader(t *testing.T) {
+	out, errc := StreamSubChunks(context.Background(), strings.NewReader(""), 40, 10)
+	chunks, err := drainStream(t, out, errc)
+	if err != nil {
+		t.Fatalf("StreamSubChunks(empty) returned error: %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Errorf("StreamSubChunks(empty) = %d chunks, want 0", len(chunks))
+	}
+}
+
+// slowReader returns at most step bytes per Read call, to force callers
+// that assume a single Read fills the buffer to handle partial reads
+// correctly.
+type slowReader struct {
+	data []byte
+	pos  int
+	step int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+	n := r.step
+	if n > len(p) {
+		n = len(p)
+	}
+	if r.pos+n > len(r.data) {
+		n = len(r.data) - r.pos
+	}
+	copy(p, r.data[r.pos:r.pos+n])
+	r.pos += n
+	return n, nil
+}
@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// TokenCounter abstracts how many model tokens a string costs, so chunk
+// budgeting can be driven by the tokenizer the embedding/NER model
+// actually runs instead of a proxy that can under- or over-count it.
+type TokenCounter interface {
+	// Count returns how many model tokens s costs.
+	Count(s string) int
+}
+
+// RegexTokenCounter counts one token per tokenRegex match — the heuristic
+// CreateSubChunks always used. It's a cheap proxy for GLiNER's real
+// subword count: accurate enough for plain English prose, but it
+// under-counts CJK text, source code, and long compound words, any of
+// which a real tokenizer splits into several subword tokens per regex
+// match.
+type RegexTokenCounter struct{}
+
+func (RegexTokenCounter) Count(s string) int {
+	return len(tokenRegex.FindAllIndex([]byte(s), -1))
+}
+
+// WordPieceTokenCounter counts exact subword tokens using a vocabulary
+// loaded from a HuggingFace tokenizers-format tokenizer.json (GLiNER's own
+// tokenizer file), so a chunk window built against maxTokens can't
+// silently exceed the model's real token limit the way RegexTokenCounter
+// can.
+type WordPieceTokenCounter struct {
+	vocab      map[string]struct{}
+	subPrefix  string // continuing_subword_prefix, e.g. "##"
+	maxWordLen int    // max_input_chars_per_word
+}
+
+// tokenizerJSON is the slice of HuggingFace's tokenizer.json this package
+// actually reads; every other field (normalizer, pre_tokenizer,
+// post_processor, decoder, added_tokens) is ignored.
+type tokenizerJSON struct {
+	Model struct {
+		Type                    string         `json:"type"`
+		Vocab                   map[string]int `json:"vocab"`
+		ContinuingSubwordPrefix string         `json:"continuing_subword_prefix"`
+		MaxInputCharsPerWord    int            `json:"max_input_chars_per_word"`
+	} `json:"model"`
+}
+
+// LoadWordPieceTokenCounter reads a HuggingFace tokenizer.json at path and
+// builds a WordPieceTokenCounter from its vocabulary. Returns an error if
+// the file can't be read/parsed, or if its model type isn't "WordPiece" —
+// GLiNER's BERT-family backbones use WordPiece; a BPE tokenizer.json needs
+// a merge-based algorithm this doesn't implement.
+func LoadWordPieceTokenCounter(path string) (*WordPieceTokenCounter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tj tokenizerJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if tj.Model.Type != "WordPiece" {
+		return nil, fmt.Errorf("%s: unsupported tokenizer model type %q (only WordPiece is supported)", path, tj.Model.Type)
+	}
+
+	vocab := make(map[string]struct{}, len(tj.Model.Vocab))
+	for tok := range tj.Model.Vocab {
+		vocab[tok] = struct{}{}
+	}
+
+	prefix := tj.Model.ContinuingSubwordPrefix
+	if prefix == "" {
+		prefix = "##"
+	}
+	maxWordLen := tj.Model.MaxInputCharsPerWord
+	if maxWordLen <= 0 {
+		maxWordLen = 100
+	}
+
+	return &WordPieceTokenCounter{vocab: vocab, subPrefix: prefix, maxWordLen: maxWordLen}, nil
+}
+
+// Count runs the standard greedy longest-match-first WordPiece algorithm
+// over each tokenRegex word in s — the same pre-tokenization
+// CreateSubChunks' byte offsets are built from — and sums the subword
+// count each word decomposes into.
+func (c *WordPieceTokenCounter) Count(s string) int {
+	total := 0
+	for _, loc := range tokenRegex.FindAllIndex([]byte(s), -1) {
+		total += c.countWord(s[loc[0]:loc[1]])
+	}
+	return total
+}
+
+// countWord applies WordPiece's greedy longest-match-first subword split
+// to a single pre-tokenized word. A word longer than maxWordLen, or one
+// containing a position no vocabulary entry covers, costs exactly 1
+// token — the same fallback a real WordPiece tokenizer gives unk_token.
+func (c *WordPieceTokenCounter) countWord(word string) int {
+	runes := []rune(word)
+	if len(runes) > c.maxWordLen {
+		return 1
+	}
+
+	count := 0
+	start := 0
+	for start < len(runes) {
+		end := len(runes)
+		matched := false
+		for end > start {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = c.subPrefix + piece
+			}
+			if _, ok := c.vocab[piece]; ok {
+				matched = true
+				break
+			}
+			end--
+		}
+		if !matched {
+			return 1
+		}
+		count++
+		start = end
+	}
+	return count
+}
+
+var (
+	_ TokenCounter = RegexTokenCounter{}
+	_ TokenCounter = (*WordPieceTokenCounter)(nil)
+)
@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegexTokenCounterCount(t *testing.T) {
+	cases := map[string]int{
+		"hello world":               2,
+		"":                          0,
+		"don't stop":                4, // tokenRegex's [-_] rule doesn't cover apostrophes, so "don't" splits into "don", "'", "t"
+		"multi-word-compound token": 2,
+	}
+	for s, want := range cases {
+		if got := (RegexTokenCounter{}).Count(s); got != want {
+			t.Errorf("RegexTokenCounter{}.Count(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func newTestWordPieceCounter() *WordPieceTokenCounter {
+	vocab := map[string]struct{}{
+		"un":       {},
+		"##believ": {},
+		"##able":   {},
+		"hello":    {},
+		"world":    {},
+	}
+	return &WordPieceTokenCounter{vocab: vocab, subPrefix: "##", maxWordLen: 100}
+}
+
+func TestWordPieceTokenCounterGreedySplit(t *testing.T) {
+	c := newTestWordPieceCounter()
+
+	if got := c.countWord("hello"); got != 1 {
+		t.Errorf("countWord(hello) = %d, want 1", got)
+	}
+	if got := c.countWord("unbelievable"); got != 3 {
+		t.Errorf("countWord(unbelievable) = %d, want 3 (un + ##believ + ##able)", got)
+	}
+}
+
+func TestWordPieceTokenCounterUnknownFallback(t *testing.T) {
+	c := newTestWordPieceCounter()
+	if got := c.countWord("xyzzy"); got != 1 {
+		t.Errorf("countWord(xyzzy) = %d, want 1 (unk fallback)", got)
+	}
+}
+
+func TestWordPieceTokenCounterMaxWordLen(t *testing.T) {
+	vocab := map[string]struct{}{"a": {}}
+	c := &WordPieceTokenCounter{vocab: vocab, subPrefix: "##", maxWordLen: 5}
+	if got := c.countWord("aaaaaaaaaa"); got != 1 {
+		t.Errorf("countWord of a 10-char word with maxWordLen 5 = %d, want 1 (unk fallback)", got)
+	}
+}
+
+func TestWordPieceTokenCounterCountSumsWords(t *testing.T) {
+	c := newTestWordPieceCounter()
+	if got := c.Count("hello unbelievable world"); got != 5 {
+		t.Errorf("Count(\"hello unbelievable world\") = %d, want 5 (1 + 3 + 1)", got)
+	}
+}
+
+func TestLoadWordPieceTokenCounter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokenizer.json")
+
+	tj := map[string]interface{}{
+		"model": map[string]interface{}{
+			"type": "WordPiece",
+			"vocab": map[string]int{
+				"hello": 0,
+				"world": 1,
+				"##ing": 2,
+				"jump":  3,
+			},
+			"continuing_subword_prefix": "##",
+		},
+	}
+	data, err := json.Marshal(tj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	counter, err := LoadWordPieceTokenCounter(path)
+	if err != nil {
+		t.Fatalf("LoadWordPieceTokenCounter returned error: %v", err)
+	}
+	if got := counter.Count("jumping"); got != 2 {
+		t.Errorf("Count(jumping) = %d, want 2 (jump + ##ing)", got)
+	}
+}
+
+func TestLoadWordPieceTokenCounterRejectsBPE(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokenizer.json")
+
+	tj := map[string]interface{}{
+		"model": map[string]interface{}{
+			"type":  "BPE",
+			"vocab": map[string]int{"hello": 0},
+		},
+	}
+	data, _ := json.Marshal(tj)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadWordPieceTokenCounter(path); err == nil {
+		t.Error("LoadWordPieceTokenCounter with a BPE tokenizer.json returned no error, want rejection")
+	}
+}
+
+func TestLoadWordPieceTokenCounterMissingFile(t *testing.T) {
+	if _, err := LoadWordPieceTokenCounter("/nonexistent/tokenizer.json"); err == nil {
+		t.Error("LoadWordPieceTokenCounter with a missing file returned no error")
+	}
+}
+
+func TestCreateSubChunksUsesCounterBudget(t *testing.T) {
+	// A counter that costs every word 5 tokens should produce far smaller
+	// windows than RegexTokenCounter's 1-token-per-word default, for the
+	// same maxTokens.
+	five := constCostCounter{cost: 5}
+	text := "one two three four five six seven eight nine ten"
+
+	cheapChunks := CreateSubChunks(text, RegexTokenCounter{}, nil, 10, 0)
+	costlyChunks := CreateSubChunks(text, five, nil, 10, 0)
+
+	if len(costlyChunks) <= len(cheapChunks) {
+		t.Errorf("got %d chunks with a 5x-costlier counter, want more than the %d from RegexTokenCounter", len(costlyChunks), len(cheapChunks))
+	}
+}
+
+type constCostCounter struct{ cost int }
+
+func (c constCostCounter) Count(s string) int {
+	return len(tokenRegex.FindAllStringIndex(s, -1)) * c.cost
+}
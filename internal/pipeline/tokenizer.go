@@ -1,7 +1,11 @@
 package pipeline
 
 import (
+	"context"
+	"io"
 	"regexp"
+	"sort"
+	"strings"
 )
 
 // WhitespaceTokenSplitter mimics the python re.compile(r'\w+(?:[-_]\w+)*|\S')
@@ -15,10 +19,31 @@ type SubChunk struct {
 	EndCharIdx   int
 }
 
-// CreateSubChunks splits a large string into overlapping chunks based on TOKEN count.
+// CreateSubChunks splits text into overlapping chunks, growing each window
+// word-by-word (tokenRegex's word boundaries) until adding the next word
+// would exceed maxTokens in counter's real model tokens — not a fixed
+// count of regex matches, so a run of CJK text, source code, or long
+// compound words can't silently push a chunk past what the ONNX runtime
+// will accept. counter is typically RegexTokenCounter{} (cheap heuristic,
+// matches this function's original behavior) or a *WordPieceTokenCounter
+// loaded from the model's own tokenizer.json for an exact budget.
+//
+// The overlap between consecutive chunks snaps to the nearest sentence
+// boundary detector finds (within ±25% of overlap tokens of the requested
+// amount), falling back to the raw overlap-token cut if no boundary
+// qualifies. A GLiNER entity span that straddles a chunk boundary is the
+// main source of duplicate/partial entities after the merge/dedup pass;
+// keeping whole sentences on both sides of the overlap avoids that.
+// detector nil means RegexBoundaryDetector{}.
 // maxTokens: 512 (GLiNER limit) - safety margin (e.g. use 450)
 // overlap: 50 tokens
-func CreateSubChunks(text string, maxTokens int, overlap int) []SubChunk {
+func CreateSubChunks(text string, counter TokenCounter, detector BoundaryDetector, maxTokens int, overlap int) []SubChunk {
+	if counter == nil {
+		counter = RegexTokenCounter{}
+	}
+	if detector == nil {
+		detector = RegexBoundaryDetector{}
+	}
 	if maxTokens <= 0 {
 		maxTokens = 400 // Safe default
 	}
@@ -26,44 +51,496 @@ func CreateSubChunks(text string, maxTokens int, overlap int) []SubChunk {
 		overlap = maxTokens / 10
 	}
 
-	// 1. Find all tokens and their byte positions
-	// FindAllStringIndex returns [[start, end], [start, end], ...]
-	tokenIndices := tokenRegex.FindAllStringIndex(text, -1)
-
-	if len(tokenIndices) == 0 {
+	// 1. Find all words and their byte positions, and cost each one in
+	// real model tokens up front so the greedy grow/overlap loop below
+	// never has to re-tokenize the same word twice.
+	wordIdx := tokenRegex.FindAllStringIndex(text, -1)
+	if len(wordIdx) == 0 {
 		return []SubChunk{}
 	}
 
-	var chunks []SubChunk
-	totalTokens := len(tokenIndices)
-
-	// 2. Iterate with window
-	step := maxTokens - overlap
+	costs := make([]int, len(wordIdx))
+	prefix := make([]int, len(wordIdx)+1)
+	for i, loc := range wordIdx {
+		costs[i] = counter.Count(text[loc[0]:loc[1]])
+		prefix[i+1] = prefix[i] + costs[i]
+	}
 
-	for i := 0; i < totalTokens; i += step {
-		end := i + maxTokens
-		if end > totalTokens {
-			end = totalTokens
-		}
+	// isBoundary[k] is true if a word starting at wordIdx[k][0] sits right
+	// after a sentence terminator, i.e. word k is a valid overlap start.
+	boundaryBytes := make(map[int]bool, 16)
+	for _, b := range detector.Boundaries(text) {
+		boundaryBytes[b] = true
+	}
+	isBoundary := make([]bool, len(wordIdx))
+	for k, loc := range wordIdx {
+		isBoundary[k] = boundaryBytes[loc[0]]
+	}
 
-		// Get the start byte of the first token in this window
-		startByte := tokenIndices[i][0]
-		// Get the end byte of the last token in this window
-		endByte := tokenIndices[end-1][1]
+	slack := overlap / 4
 
-		subText := text[startByte:endByte]
+	var chunks []SubChunk
+	i := 0
+	for i < len(wordIdx) {
+		// 2. Grow the window greedily: keep adding words while they still
+		// fit under maxTokens real tokens.
+		budget := 0
+		j := i
+		for j < len(wordIdx) {
+			cost := costs[j]
+			if budget > 0 && budget+cost > maxTokens {
+				break
+			}
+			budget += cost
+			j++
+			if budget >= maxTokens {
+				break
+			}
+		}
+		if j == i {
+			j = i + 1 // a single word over maxTokens still has to go somewhere
+		}
 
+		startByte := wordIdx[i][0]
+		endByte := wordIdx[j-1][1]
 		chunks = append(chunks, SubChunk{
-			Text:         subText,
+			Text:         text[startByte:endByte],
 			StartCharIdx: startByte,
 			EndCharIdx:   endByte,
 		})
 
-		// Optimization: If we reached the end, stop
-		if end == totalTokens {
+		if j == len(wordIdx) {
 			break
 		}
+
+		// 3. Step the next window's start back by `overlap` real tokens
+		// worth of words, then snap that start to the nearest sentence
+		// boundary within `slack` tokens of the requested overlap, so
+		// consecutive chunks share whole sentences of context instead of
+		// a mid-sentence fragment.
+		back := j
+		overlapBudget := 0
+		for back > i && overlapBudget < overlap {
+			back--
+			overlapBudget += costs[back]
+		}
+		if back <= i {
+			back = j
+		} else if overlap > 0 {
+			back = snapToSentenceBoundary(prefix, isBoundary, i, j, back, overlap, slack)
+		}
+		i = back
 	}
 
 	return chunks
 }
+
+// snapToSentenceBoundary looks for a word index in (i, j) that both sits
+// right after a sentence terminator (isBoundary) and costs within slack
+// real tokens of the requested overlap when measured back from j, and
+// returns whichever such index is closest to overlap. Returns target
+// (the raw, non-boundary-aware overlap cut) unchanged if none qualifies.
+func snapToSentenceBoundary(prefix []int, isBoundary []bool, i, j, target, overlap, slack int) int {
+	best := target
+	bestDiff := -1
+	for idx := i + 1; idx < j; idx++ {
+		if !isBoundary[idx] {
+			continue
+		}
+		cost := prefix[j] - prefix[idx]
+		diff := cost - overlap
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > slack {
+			continue
+		}
+		if bestDiff == -1 || diff < bestDiff {
+			best, bestDiff = idx, diff
+		}
+	}
+	return best
+}
+
+// recursiveSeparators is the hierarchy CreateRecursiveSubChunks tries
+// before falling back to a raw token window, ordered coarsest first:
+// paragraph break, line break, sentence end, then plain whitespace.
+var recursiveSeparators = []string{"\n\n", "\n", ". ", " "}
+
+// fragment is a [start,end) byte range into the text CreateRecursiveSubChunks
+// was called with, produced by splitRecursive before overlap is stitched in.
+type fragment struct {
+	start, end int
+}
+
+// countTokens reports how many GLiNER-style tokens s contains.
+func countTokens(s string) int {
+	return len(tokenRegex.FindAllStringIndex(s, -1))
+}
+
+// splitRecursive recurses into text[start:end), trying each separator in
+// seps in turn. The first separator that actually occurs in the segment is
+// applied, and every resulting piece still exceeding maxTokens is recursed
+// into with the remaining (narrower) separator hierarchy. A segment that
+// already fits, or for which no remaining separator occurs, is returned
+// as-is — CreateRecursiveSubChunks falls back to CreateSubChunks' token
+// windowing for any leaf that's still oversized once seps is exhausted.
+func splitRecursive(text string, start, end int, seps []string, maxTokens int) []fragment {
+	seg := text[start:end]
+	if countTokens(seg) <= maxTokens || len(seps) == 0 {
+		return []fragment{{start, end}}
+	}
+
+	parts := splitOnSeparator(seg, seps[0])
+	if len(parts) < 2 {
+		return splitRecursive(text, start, end, seps[1:], maxTokens)
+	}
+
+	var out []fragment
+	offset := start
+	for _, p := range parts {
+		pStart, pEnd := offset, offset+len(p)
+		if pStart < pEnd {
+			out = append(out, splitRecursive(text, pStart, pEnd, seps[1:], maxTokens)...)
+		}
+		offset = pEnd
+	}
+	return out
+}
+
+// splitOnSeparator splits seg on sep, keeping sep attached to the end of
+// each piece (except the final one) so concatenating the returned pieces
+// reproduces seg exactly and byte offsets stay trackable.
+func splitOnSeparator(seg, sep string) []string {
+	raw := strings.Split(seg, sep)
+	if len(raw) < 2 {
+		return raw
+	}
+	parts := make([]string, len(raw))
+	for i, p := range raw {
+		if i < len(raw)-1 {
+			parts[i] = p + sep
+		} else {
+			parts[i] = p
+		}
+	}
+	return parts
+}
+
+// extendStartForOverlap walks tokenIndices (token offsets for the whole
+// text) backward from the token starting at pos by overlap tokens,
+// returning the byte offset to extend a fragment's start to so it picks up
+// the tail of the previous fragment as context — the same overlap
+// CreateSubChunks gives its sliding token windows, applied across
+// CreateRecursiveSubChunks' semantic fragment boundaries instead.
+func extendStartForOverlap(tokenIndices [][]int, pos int, overlap int) int {
+	idx := sort.Search(len(tokenIndices), func(i int) bool { return tokenIndices[i][0] >= pos })
+	if idx <= 0 {
+		return pos
+	}
+	back := idx - overlap
+	if back < 0 {
+		back = 0
+	}
+	return tokenIndices[back][0]
+}
+
+// CreateRecursiveSubChunks splits text into fragments of <=maxTokens tokens,
+// then extends every fragment after the first back by overlap tokens from
+// the previous piece (so such a chunk can hold up to maxTokens+overlap
+// tokens). It first tries to cut along recursiveSeparators so a boundary
+// lands on a paragraph/sentence/word break instead of wherever the token
+// count happens to land. This preserves paragraph/sentence coherence for
+// downstream GLiNER NER, which the pure token windowing in CreateSubChunks
+// routinely cuts through. Falls back to CreateSubChunks for any fragment
+// that's still oversized once the separator hierarchy is exhausted.
+func CreateRecursiveSubChunks(text string, maxTokens int, overlap int) []SubChunk {
+	if maxTokens <= 0 {
+		maxTokens = 400
+	}
+	if overlap >= maxTokens {
+		overlap = maxTokens / 10
+	}
+
+	fragments := splitRecursive(text, 0, len(text), recursiveSeparators, maxTokens)
+	tokenIndices := tokenRegex.FindAllStringIndex(text, -1)
+
+	chunks := make([]SubChunk, 0, len(fragments))
+	for i, f := range fragments {
+		if f.start >= f.end {
+			continue
+		}
+
+		orig := text[f.start:f.end]
+		if countTokens(orig) > maxTokens {
+			for _, sc := range CreateSubChunks(orig, RegexTokenCounter{}, nil, maxTokens, overlap) {
+				chunks = append(chunks, SubChunk{
+					Text:         sc.Text,
+					StartCharIdx: f.start + sc.StartCharIdx,
+					EndCharIdx:   f.start + sc.EndCharIdx,
+				})
+			}
+			continue
+		}
+
+		start := f.start
+		if i > 0 && overlap > 0 {
+			start = extendStartForOverlap(tokenIndices, f.start, overlap)
+		}
+		chunks = append(chunks, SubChunk{
+			Text:         text[start:f.end],
+			StartCharIdx: start,
+			EndCharIdx:   f.end,
+		})
+	}
+
+	return chunks
+}
+
+// gearTable is a fixed table of pseudo-random 64-bit values, one per
+// possible byte value, used by CreateContentDefinedSubChunks' rolling hash
+// to mix a byte in at O(1) per byte — the same "gear hashing" FastCDC uses.
+// Seeded with a fixed constant (splitmix64) so the table, and therefore
+// where chunk boundaries land, is stable across runs and machines.
+var gearTable = func() [256]uint64 {
+	var t [256]uint64
+	seed := uint64(0x9E3779B97F4A7C15)
+	for i := range t {
+		seed += 0x9E3779B97F4A7C15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		z = z ^ (z >> 31)
+		t[i] = z
+	}
+	return t
+}()
+
+// foldGearHash feeds s's bytes through gearTable into the running hash h
+// one byte at a time, via the fixed left shift gear hashing uses. Because
+// every existing bit is shifted one place left per byte, a byte's
+// contribution is gone from h once ~64 more bytes have been folded in
+// after it — h is a function of only the last ~64 bytes seen, not of
+// everything since the caller last reset it (or never did at all).
+func foldGearHash(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h = (h << 1) + gearTable[s[i]]
+	}
+	return h
+}
+
+// tokenFingerprint folds tok's bytes through gearTable into a single
+// 64-bit fingerprint, starting from a zero hash.
+func tokenFingerprint(tok string) uint64 {
+	return foldGearHash(0, tok)
+}
+
+// maskForAverage returns a bitmask sized so that, for token fingerprints
+// uniformly distributed over 64 bits, roughly 1 in avgTokens will satisfy
+// hash&mask == 0 — CreateContentDefinedSubChunks' cut-point test.
+func maskForAverage(avgTokens int) uint64 {
+	if avgTokens < 2 {
+		return 0
+	}
+	bits := 0
+	for (1 << uint(bits)) < avgTokens {
+		bits++
+	}
+	return (uint64(1) << uint(bits)) - 1
+}
+
+// CreateContentDefinedSubChunks splits text into sub-chunks the same shape
+// as CreateSubChunks, but places boundaries at content-derived "cut
+// points" (FastCDC-style gear hashing over the token stream) instead of
+// fixed token counts. It walks tokens from tokenRegex, folding each
+// token's bytes into a single rolling 64-bit hash via foldGearHash, and
+// cuts as soon as hash&mask == 0 once the chunk has reached minTokens —
+// clamping every chunk but the last to [minTokens, maxTokens] regardless of
+// where the hash lands (the last chunk is whatever remains, at most maxTokens). Critically, the hash is never reset at a cut: foldGearHash's
+// fixed left shift already bounds its memory to the last ~64 bytes, so
+// cut decisions depend only on nearby content, not on where the previous
+// cut happened to fall.
+//
+// Editing one paragraph only shifts the boundaries immediately around that
+// edit; once the rolling hash has folded in ~64 bytes of unedited text
+// past the edit, it matches what it would have been with no edit at all,
+// so every chunk after that point comes out byte-for-byte identical to
+// before. That lets re-indexing after a small edit reuse most of the
+// previous run's embeddings instead of recomputing every chunk downstream
+// of the edit the way a fixed token window (CreateSubChunks) would force.
+func CreateContentDefinedSubChunks(text string, avgTokens, minTokens, maxTokens int) []SubChunk {
+	if maxTokens <= 0 {
+		maxTokens = 400
+	}
+	if minTokens <= 0 || minTokens > maxTokens {
+		minTokens = maxTokens / 4
+	}
+	if avgTokens <= 0 || avgTokens > maxTokens {
+		avgTokens = (minTokens + maxTokens) / 2
+	}
+	mask := maskForAverage(avgTokens)
+
+	tokenIndices := tokenRegex.FindAllStringIndex(text, -1)
+	if len(tokenIndices) == 0 {
+		return []SubChunk{}
+	}
+
+	var chunks []SubChunk
+	chunkStartTok := 0
+	var rollingHash uint64
+
+	for i, idx := range tokenIndices {
+		rollingHash = foldGearHash(rollingHash, text[idx[0]:idx[1]])
+		tokCount := i - chunkStartTok + 1
+
+		atCut := tokCount >= minTokens && rollingHash&mask == 0
+		atEnd := i == len(tokenIndices)-1
+		forced := tokCount >= maxTokens
+
+		if atCut || atEnd || forced {
+			startByte := tokenIndices[chunkStartTok][0]
+			endByte := idx[1]
+			chunks = append(chunks, SubChunk{
+				Text:         text[startByte:endByte],
+				StartCharIdx: startByte,
+				EndCharIdx:   endByte,
+			})
+			chunkStartTok = i + 1
+		}
+	}
+
+	return chunks
+}
+
+// streamReadSize is how many bytes StreamSubChunks pulls from r at a time
+// when it needs more tokens before it can emit the next window.
+const streamReadSize = 32 * 1024
+
+// StreamSubChunks tokenizes r incrementally and emits SubChunks on out as
+// soon as each window is complete, instead of materializing the whole
+// document and running FindAllStringIndex over it the way CreateSubChunks
+// does. It holds at most the unconsumed tail of one window plus one
+// streamReadSize read in memory (buf is trimmed after every emitted chunk,
+// and refilled only when it holds fewer than maxTokens tokens), so a multi-MB document can be
+// tokenized in bounded memory and a GLiNER worker can start on chunk 1
+// while chunk N is still being read. Offsets on each SubChunk are byte
+// offsets into r. The error channel receives at most one error (from r or
+// ctx) and, like out, is closed once the stream ends.
+func StreamSubChunks(ctx context.Context, r io.Reader, maxTokens, overlap int) (<-chan SubChunk, <-chan error) {
+	out := make(chan SubChunk)
+	errc := make(chan error, 1)
+
+	if maxTokens <= 0 {
+		maxTokens = 400
+	}
+	if overlap >= maxTokens {
+		overlap = maxTokens / 10
+	}
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		var buf []byte
+		bufBase := 0
+		eof := false
+
+		readMore := func() error {
+			chunk := make([]byte, streamReadSize)
+			n, err := r.Read(chunk)
+			if n > 0 {
+				buf = append(buf, chunk[:n]...)
+			}
+			if err == io.EOF {
+				eof = true
+				return nil
+			}
+			return err
+		}
+
+		send := func(sc SubChunk) bool {
+			select {
+			case out <- sc:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				errc <- err
+				return
+			}
+
+			tokens := tokenRegex.FindAllIndex(buf, -1)
+
+			// A token touching the very end of buf might still be growing
+			// (e.g. a word cut off where the last read happened to stop),
+			// so don't trust it as complete until eof confirms there's
+			// nothing more to extend it.
+			usable := tokens
+			if !eof && len(tokens) > 0 && tokens[len(tokens)-1][1] == len(buf) {
+				usable = tokens[:len(tokens)-1]
+			}
+
+			if len(usable) == 0 {
+				if eof {
+					return
+				}
+				if err := readMore(); err != nil {
+					errc <- err
+					return
+				}
+				continue
+			}
+
+			if len(usable) < maxTokens && !eof {
+				if err := readMore(); err != nil {
+					errc <- err
+					return
+				}
+				continue
+			}
+
+			windowLen := maxTokens
+			if windowLen > len(usable) {
+				windowLen = len(usable)
+			}
+
+			startByte := usable[0][0]
+			endByte := usable[windowLen-1][1]
+
+			ok := send(SubChunk{
+				Text:         string(buf[startByte:endByte]),
+				StartCharIdx: bufBase + startByte,
+				EndCharIdx:   bufBase + endByte,
+			})
+			if !ok {
+				return
+			}
+
+			if eof && windowLen == len(usable) {
+				return
+			}
+
+			// Slide forward by (windowLen - overlap) tokens and trim
+			// everything before the new window start out of buf, so memory
+			// stays bounded to roughly one window instead of the whole
+			// document.
+			step := windowLen - overlap
+			if step <= 0 {
+				step = 1
+			}
+			if step >= len(usable) {
+				step = len(usable) - 1
+			}
+			trimByte := usable[step][0]
+
+			bufBase += trimByte
+			buf = buf[trimByte:]
+		}
+	}()
+
+	return out, errc
+}
@@ -52,5 +52,11 @@ func (m *Manager) GetDBConfig(dbName string) (config.DBState, error) {
 		}
 	}
 
+	if ctxStr, ok := kv["embed_context_length"]; ok {
+		if ctx, err := strconv.Atoi(ctxStr); err == nil {
+			state.EmbedContextLength = ctx
+		}
+	}
+
 	return state, nil
 }
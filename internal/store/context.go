@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+)
+
+// DBHandle is what DBContext middleware attaches to a request: the
+// resolved database name, its live SQLite handle, and the on-disk path of
+// its vector index (handlers pass this as WorkerVectorCmd.Path instead of
+// assuming a single global index).
+type DBHandle struct {
+	Name      string
+	DB        *sql.DB
+	IndexPath string
+}
+
+// NewDBHandle builds a DBHandle for name using db (already opened/cached
+// by the Manager) and the Manager's own path conventions.
+func (m *Manager) NewDBHandle(name string, db *sql.DB) *DBHandle {
+	return &DBHandle{
+		Name:      name,
+		DB:        db,
+		IndexPath: filepath.Join(m.GetDBPath(name), "vectors.index"),
+	}
+}
+
+type contextKey int
+
+const dbHandleKey contextKey = iota
+
+// WithDB attaches a resolved DBHandle to ctx.
+func WithDB(ctx context.Context, handle *DBHandle) context.Context {
+	return context.WithValue(ctx, dbHandleKey, handle)
+}
+
+// FromContext retrieves the DBHandle attached by DBContext middleware, if
+// any. Handlers that need a DB should fall back to a default/legacy lookup
+// when ok is false, since not every route is guaranteed to run behind the
+// middleware (e.g. in tests).
+func FromContext(ctx context.Context) (*DBHandle, bool) {
+	handle, ok := ctx.Value(dbHandleKey).(*DBHandle)
+	return handle, ok
+}
@@ -0,0 +1,415 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+// BackfillEntityGraph re-runs the NER worker over every chunk already
+// stored in db and populates entities/chunk_entities/entity_edges. This
+// lets existing databases gain a knowledge graph after the feature ships,
+// without re-ingesting documents from scratch. batchSize controls how
+// many chunks are sent to the worker per request.
+func BackfillEntityGraph(db *sql.DB, worker ipc.Client, labels []string, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = 20
+	}
+
+	rows, err := db.Query(`SELECT id, content FROM chunks ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("list chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	var texts []string
+	for rows.Next() {
+		var id int64
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		texts = append(texts, content)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		req := models.WorkerNERRequest{Texts: texts[start:end], Labels: labels}
+		var resp models.WorkerNERResponse
+		if err := worker.Process(context.Background(), req, &resp); err != nil {
+			return fmt.Errorf("ner worker batch %d-%d: %w", start, end, err)
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("ner worker batch %d-%d: %s", start, end, resp.Error)
+		}
+
+		for i, ents := range resp.Results {
+			chunkID := ids[start+i]
+			tx, err := db.Begin()
+			if err != nil {
+				return err
+			}
+			if err := RecordChunkEntities(tx, chunkID, ents); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("chunk %d: %w", chunkID, err)
+			}
+			if err := tx.Commit(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ==========================================
+// KNOWLEDGE GRAPH: WRITE PATH
+// ==========================================
+
+// CanonicalizeEntityText normalizes a surface form so that "Java", "java",
+// and " Java " all resolve to the same entity row. This is deliberately
+// simple (lowercase + trim); a richer alias table can sit in front of this
+// later without changing callers.
+func CanonicalizeEntityText(text string) string {
+	return strings.ToLower(strings.TrimSpace(text))
+}
+
+// RecordChunkEntities upserts every entity GLiNER found in a chunk into the
+// entities/chunk_entities tables, canonicalizing surface forms per label,
+// then adds or strengthens an entity_edges row for every unordered pair of
+// entities that co-occurred in the chunk. Must run inside a transaction
+// the caller controls so a failure mid-chunk doesn't leave a partial graph.
+func RecordChunkEntities(tx *sql.Tx, chunkID int64, ents []models.WorkerNEREntity) error {
+	entityIDs := make([]int64, 0, len(ents))
+	seen := make(map[int64]bool)
+	// confidence holds each entity's highest-scored mention in this chunk,
+	// since a single entity can surface more than once (a pronoun and its
+	// antecedent, etc). addCoOccurrenceEdges uses this to scale the edge
+	// weight it adds rather than crediting every pair equally.
+	confidence := make(map[int64]float64, len(ents))
+
+	for _, e := range ents {
+		labelID, err := upsertLabel(tx, e.Label)
+		if err != nil {
+			return fmt.Errorf("upsert label %q: %w", e.Label, err)
+		}
+
+		canon := CanonicalizeEntityText(e.Text)
+		entityID, err := upsertEntity(tx, canon, labelID)
+		if err != nil {
+			return fmt.Errorf("upsert entity %q: %w", canon, err)
+		}
+
+		offsets, _ := json.Marshal([][2]int{{e.Start, e.End}})
+		if _, err := tx.Exec(`
+			INSERT INTO chunk_entities (chunk_id, entity_id, local_frequency, offsets, confidence)
+			VALUES (?, ?, 1, ?, ?)`, chunkID, entityID, string(offsets), e.Score); err != nil {
+			return fmt.Errorf("insert chunk_entities: %w", err)
+		}
+
+		if !seen[entityID] {
+			seen[entityID] = true
+			entityIDs = append(entityIDs, entityID)
+		}
+		if score := float64(e.Score); score > confidence[entityID] {
+			confidence[entityID] = score
+		}
+	}
+
+	return addCoOccurrenceEdges(tx, chunkID, entityIDs, confidence)
+}
+
+func upsertLabel(tx *sql.Tx, name string) (int64, error) {
+	if _, err := tx.Exec(`
+		INSERT INTO labels (name, global_frequency) VALUES (?, 1)
+		ON CONFLICT(name) DO UPDATE SET global_frequency = global_frequency + 1`, name); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := tx.QueryRow(`SELECT id FROM labels WHERE name = ?`, name).Scan(&id)
+	return id, err
+}
+
+func upsertEntity(tx *sql.Tx, canonicalName string, labelID int64) (int64, error) {
+	if _, err := tx.Exec(`
+		INSERT INTO entities (name, label_id, global_frequency) VALUES (?, ?, 1)
+		ON CONFLICT(name, label_id) DO UPDATE SET global_frequency = global_frequency + 1`,
+		canonicalName, labelID); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := tx.QueryRow(`SELECT id FROM entities WHERE name = ? AND label_id = ?`, canonicalName, labelID).Scan(&id)
+	return id, err
+}
+
+// addCoOccurrenceEdges inserts/strengthens an entity_edges row for every
+// unordered pair in entityIDs. Edges are always stored with the smaller id
+// first so (a, b) and (b, a) collapse to one row. Each increment is scaled
+// by the average of the pair's NER confidence (confidence, keyed by entity
+// id) instead of a flat 1, so a co-occurrence GLiNER was unsure about
+// contributes less to the graph than one it was confident in.
+func addCoOccurrenceEdges(tx *sql.Tx, chunkID int64, entityIDs []int64, confidence map[int64]float64) error {
+	for i := 0; i < len(entityIDs); i++ {
+		for j := i + 1; j < len(entityIDs); j++ {
+			src, dst := entityIDs[i], entityIDs[j]
+			if src > dst {
+				src, dst = dst, src
+			}
+			inc := (confidence[entityIDs[i]] + confidence[entityIDs[j]]) / 2
+			if _, err := tx.Exec(`
+				INSERT INTO entity_edges (src_entity_id, dst_entity_id, weight, last_chunk_id)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT(src_entity_id, dst_entity_id)
+				DO UPDATE SET weight = weight + excluded.weight, last_chunk_id = excluded.last_chunk_id`,
+				src, dst, inc, chunkID); err != nil {
+				return fmt.Errorf("upsert entity_edges (%d,%d): %w", src, dst, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ==========================================
+// KNOWLEDGE GRAPH: READ PATH
+// ==========================================
+
+// KGMatch is one hop in the path that led the BFS to a candidate chunk,
+// used to populate SearchResult.GraphPath.
+type KGMatch struct {
+	EntityID int64
+	Name     string
+	Label    string
+	Weight   float64 // accumulated edge weight from the nearest seed
+}
+
+// EntitiesByText finds canonical entities whose name contains any of the
+// query tokens (case-insensitive substring), optionally restricted to a
+// set of labels. This is the seed-extraction fallback used until a real
+// NER pass over the query text is wired in.
+func EntitiesByText(db *sql.DB, tokens []string, labels []string) ([]int64, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	clauses := make([]string, 0, len(tokens))
+	args := make([]interface{}, 0, len(tokens)+len(labels))
+	for _, t := range tokens {
+		clauses = append(clauses, "e.name LIKE ?")
+		args = append(args, "%"+CanonicalizeEntityText(t)+"%")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT DISTINCT e.id FROM entities e
+		JOIN labels l ON l.id = e.label_id
+		WHERE (%s)`, strings.Join(clauses, " OR "))
+
+	if len(labels) > 0 {
+		placeholders := make([]string, len(labels))
+		for i, lb := range labels {
+			placeholders[i] = "?"
+			args = append(args, lb)
+		}
+		query += fmt.Sprintf(" AND l.name IN (%s)", strings.Join(placeholders, ","))
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+type weightedEdge struct {
+	entityID int64
+	weight   float64
+}
+
+// TraverseEntityGraph runs a bounded-fan-out BFS over entity_edges starting
+// from seedIDs. At each hop only the top `fanout` heaviest edges per node
+// are followed, which keeps highly-connected hubs (e.g. common labels)
+// from exploding the frontier. Returns every entity reached within `hops`
+// steps along with the best (src->entity) path weight seen.
+func TraverseEntityGraph(db *sql.DB, seedIDs []int64, hops int, fanout int) (map[int64]*KGMatch, error) {
+	if hops <= 0 {
+		hops = 1
+	}
+	if fanout <= 0 {
+		fanout = 10
+	}
+
+	visited := make(map[int64]*KGMatch, len(seedIDs))
+	for _, id := range seedIDs {
+		name, label, err := lookupEntity(db, id)
+		if err != nil {
+			continue
+		}
+		visited[id] = &KGMatch{EntityID: id, Name: name, Label: label, Weight: 1}
+	}
+
+	frontier := append([]int64{}, seedIDs...)
+	for hop := 0; hop < hops && len(frontier) > 0; hop++ {
+		var next []int64
+		for _, nodeID := range frontier {
+			edges, err := topEdges(db, nodeID, fanout)
+			if err != nil {
+				continue
+			}
+			for _, e := range edges {
+				decay := visited[nodeID].Weight * e.weight / float64(hop+1)
+				if existing, ok := visited[e.entityID]; ok {
+					if decay > existing.Weight {
+						existing.Weight = decay
+					}
+					continue
+				}
+				name, label, err := lookupEntity(db, e.entityID)
+				if err != nil {
+					continue
+				}
+				visited[e.entityID] = &KGMatch{EntityID: e.entityID, Name: name, Label: label, Weight: decay}
+				next = append(next, e.entityID)
+			}
+		}
+		frontier = next
+	}
+
+	return visited, nil
+}
+
+func lookupEntity(db *sql.DB, id int64) (name string, label string, err error) {
+	err = db.QueryRow(`
+		SELECT e.name, l.name FROM entities e
+		JOIN labels l ON l.id = e.label_id
+		WHERE e.id = ?`, id).Scan(&name, &label)
+	return
+}
+
+// topEdges returns the `limit` heaviest edges touching entityID, in either
+// direction, ordered by weight descending.
+func topEdges(db *sql.DB, entityID int64, limit int) ([]weightedEdge, error) {
+	rows, err := db.Query(`
+		SELECT CASE WHEN src_entity_id = ? THEN dst_entity_id ELSE src_entity_id END AS other,
+		       weight
+		FROM entity_edges
+		WHERE src_entity_id = ? OR dst_entity_id = ?
+		ORDER BY weight DESC
+		LIMIT ?`, entityID, entityID, entityID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []weightedEdge
+	for rows.Next() {
+		var e weightedEdge
+		if err := rows.Scan(&e.entityID, &e.weight); err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// KGChunkHit is a scored candidate chunk surfaced by the graph traversal,
+// ready to be merged with a semantic similarity term by the caller.
+type KGChunkHit struct {
+	ChunkID   int64
+	DocID     int64
+	Content   string
+	GraphPath string
+	Score     float64
+}
+
+// ScoreChunksByEntityMatches sums, per chunk, the weight of every matched
+// entity that appears in it, and returns the topK highest-scoring chunks.
+func ScoreChunksByEntityMatches(db *sql.DB, matches map[int64]*KGMatch, topK int) ([]KGChunkHit, error) {
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(matches))
+	args := make([]interface{}, 0, len(matches))
+	for id := range matches {
+		ids = append(ids, "?")
+		args = append(args, id)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT ce.chunk_id, ce.entity_id, c.doc_id, c.content
+		FROM chunk_entities ce
+		JOIN chunks c ON c.id = ce.chunk_id
+		WHERE ce.entity_id IN (%s)`, strings.Join(ids, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type accum struct {
+		docID   int64
+		content string
+		score   float64
+		path    []string
+	}
+	byChunk := make(map[int64]*accum)
+
+	for rows.Next() {
+		var chunkID, entityID, docID int64
+		var content string
+		if err := rows.Scan(&chunkID, &entityID, &docID, &content); err != nil {
+			return nil, err
+		}
+		a, ok := byChunk[chunkID]
+		if !ok {
+			a = &accum{docID: docID, content: content}
+			byChunk[chunkID] = a
+		}
+		m := matches[entityID]
+		a.score += m.Weight
+		a.path = append(a.path, m.Name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	hits := make([]KGChunkHit, 0, len(byChunk))
+	for chunkID, a := range byChunk {
+		hits = append(hits, KGChunkHit{
+			ChunkID:   chunkID,
+			DocID:     a.docID,
+			Content:   a.content,
+			GraphPath: strings.Join(a.path, " -> "),
+			Score:     a.score,
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if topK > 0 && len(hits) > topK {
+		hits = hits[:topK]
+	}
+	return hits, nil
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newKGTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite3: %v", err)
+	}
+	if _, err := db.Exec(SchemaSQL); err != nil {
+		t.Fatalf("apply schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func insertTestChunk(t *testing.T, db *sql.DB) int64 {
+	t.Helper()
+	res, err := db.Exec(`INSERT INTO chunks (doc_id, chunk_index, content) VALUES (1, 0, 'test chunk')`)
+	if err != nil {
+		t.Fatalf("insert chunk: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatalf("chunk id: %v", err)
+	}
+	return id
+}
+
+func TestRecordChunkEntitiesPersistsConfidence(t *testing.T) {
+	db := newKGTestDB(t)
+	chunkID := insertTestChunk(t, db)
+
+	ents := []models.WorkerNEREntity{
+		{Text: "Elon Musk", Label: "PERSON", Start: 0, End: 9, Score: 0.92},
+		{Text: "Tesla", Label: "ORG", Start: 10, End: 15, Score: 0.55},
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := RecordChunkEntities(tx, chunkID, ents); err != nil {
+		t.Fatalf("RecordChunkEntities: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT e.name, ce.confidence FROM chunk_entities ce JOIN entities e ON e.id = ce.entity_id ORDER BY ce.confidence DESC`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var got []struct {
+		name       string
+		confidence float64
+	}
+	for rows.Next() {
+		var name string
+		var confidence float64
+		if err := rows.Scan(&name, &confidence); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		got = append(got, struct {
+			name       string
+			confidence float64
+		}{name, confidence})
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d chunk_entities rows, want 2", len(got))
+	}
+	if got[0].name != "elon musk" || got[0].confidence < 0.919 || got[0].confidence > 0.921 {
+		t.Errorf("top row = %+v, want elon musk @ ~0.92", got[0])
+	}
+	if got[1].name != "tesla" || got[1].confidence < 0.549 || got[1].confidence > 0.551 {
+		t.Errorf("second row = %+v, want tesla @ ~0.55", got[1])
+	}
+}
+
+func TestAddCoOccurrenceEdgesScalesWeightByConfidence(t *testing.T) {
+	db := newKGTestDB(t)
+
+	highConfChunk := insertTestChunk(t, db)
+	highConf := []models.WorkerNEREntity{
+		{Text: "Alice", Label: "PERSON", Score: 1.0},
+		{Text: "Bob", Label: "PERSON", Score: 1.0},
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := RecordChunkEntities(tx, highConfChunk, highConf); err != nil {
+		t.Fatalf("RecordChunkEntities high-conf: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	var highWeight float64
+	if err := db.QueryRow(`SELECT weight FROM entity_edges`).Scan(&highWeight); err != nil {
+		t.Fatalf("read weight: %v", err)
+	}
+	if highWeight != 1.0 {
+		t.Errorf("weight after one high-confidence co-occurrence = %v, want 1.0", highWeight)
+	}
+
+	lowConfChunk := insertTestChunk(t, db)
+	lowConf := []models.WorkerNEREntity{
+		{Text: "Alice", Label: "PERSON", Score: 0.2},
+		{Text: "Bob", Label: "PERSON", Score: 0.2},
+	}
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := RecordChunkEntities(tx, lowConfChunk, lowConf); err != nil {
+		t.Fatalf("RecordChunkEntities low-conf: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	var combinedWeight float64
+	if err := db.QueryRow(`SELECT weight FROM entity_edges`).Scan(&combinedWeight); err != nil {
+		t.Fatalf("read weight: %v", err)
+	}
+	// 1.0 (first co-occurrence) + 0.2 (second, low-confidence co-occurrence)
+	if combinedWeight < 1.19 || combinedWeight > 1.21 {
+		t.Errorf("weight after a low-confidence second co-occurrence = %v, want ~1.2", combinedWeight)
+	}
+}
+
+func TestTraverseEntityGraphWeightsByConfidence(t *testing.T) {
+	db := newKGTestDB(t)
+	chunkID := insertTestChunk(t, db)
+
+	ents := []models.WorkerNEREntity{
+		{Text: "Alice", Label: "PERSON", Score: 1.0},
+		{Text: "Bob", Label: "PERSON", Score: 0.1},
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := RecordChunkEntities(tx, chunkID, ents); err != nil {
+		t.Fatalf("RecordChunkEntities: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	var aliceID int64
+	if err := db.QueryRow(`SELECT id FROM entities WHERE name = 'alice'`).Scan(&aliceID); err != nil {
+		t.Fatalf("lookup alice: %v", err)
+	}
+
+	matches, err := TraverseEntityGraph(db, []int64{aliceID}, 1, 10)
+	if err != nil {
+		t.Fatalf("TraverseEntityGraph: %v", err)
+	}
+
+	var bobMatch *KGMatch
+	for _, m := range matches {
+		if m.Name == "bob" {
+			bobMatch = m
+		}
+	}
+	if bobMatch == nil {
+		t.Fatal("bob not reached by traversal from alice")
+	}
+	// edge weight is (1.0+0.1)/2 = 0.55, decayed by hop 1: 1 * 0.55 / 1 = 0.55
+	if bobMatch.Weight < 0.54 || bobMatch.Weight > 0.56 {
+		t.Errorf("bob's traversal weight = %v, want ~0.55 (scaled by low NER confidence)", bobMatch.Weight)
+	}
+}
@@ -3,11 +3,15 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/GonzoDMX/rag-anywhere/internal/config"
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -21,6 +25,17 @@ const (
 // Manager handles the physical file resources and database lifecycle
 type Manager struct {
 	RootDir string
+
+	// VectorWorker is the Python vector worker pool VectorIndexFor hands to
+	// NewVectorIndex for the "faiss" backend. main.go leaves it nil (its
+	// assignment is commented out), so NewVectorIndex refuses "faiss" until
+	// a caller sets it; databases using "sqlite_brute" or "hnsw" never need it.
+	VectorWorker ipc.Client
+
+	mu            sync.Mutex
+	openDBs       map[string]*sql.DB     // cache of live *sql.DB handles, keyed by database name
+	vectorIndexes map[string]VectorIndex // cache of live VectorIndex handles, keyed by database name
+	migrating     map[string]bool        // set of database names with a Migrator.Run in flight
 }
 
 // NewManager creates the directory structure if it doesn't exist
@@ -44,7 +59,164 @@ func NewManager() (*Manager, error) {
 		}
 	}
 
-	return &Manager{RootDir: root}, nil
+	return &Manager{
+		RootDir:       root,
+		openDBs:       make(map[string]*sql.DB),
+		vectorIndexes: make(map[string]VectorIndex),
+		migrating:     make(map[string]bool),
+	}, nil
+}
+
+// TryStartMigration marks name as having a migration in flight and reports
+// whether it succeeded — false means a migration against this database is
+// already running, so the caller must not construct a second Migrator for
+// it. Callers must pair a successful TryStartMigration with a deferred
+// FinishMigration once Migrator.Run returns, or the database is locked out
+// of migration forever.
+func (m *Manager) TryStartMigration(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.migrating[name] {
+		return false
+	}
+	m.migrating[name] = true
+	return true
+}
+
+// FinishMigration clears the in-flight marker TryStartMigration set for
+// name, letting a future migration against this database proceed.
+func (m *Manager) FinishMigration(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.migrating, name)
+}
+
+// OpenCached returns a live *sql.DB handle for name, reusing a previously
+// opened one if this Manager has already seen the request. This is what
+// lets per-request DB routing avoid re-opening the SQLite file on every
+// call.
+func (m *Manager) OpenCached(name string) (*sql.DB, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if db, ok := m.openDBs[name]; ok {
+		return db, nil
+	}
+
+	dbPath := filepath.Join(m.GetDBPath(name), "rag.db")
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("database '%s' not found", name)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	m.openDBs[name] = db
+	return db, nil
+}
+
+// VectorIndexFor returns the cached VectorIndex for name, constructing and
+// Loading it on first use via NewVectorIndex and the backend/params stamped
+// into that database's config table at CreateDatabase time.
+func (m *Manager) VectorIndexFor(name string) (VectorIndex, error) {
+	m.mu.Lock()
+	if idx, ok := m.vectorIndexes[name]; ok {
+		m.mu.Unlock()
+		return idx, nil
+	}
+	m.mu.Unlock()
+
+	db, err := m.OpenCached(name)
+	if err != nil {
+		return nil, err
+	}
+	backend, params, err := readVectorConfig(db)
+	if err != nil {
+		return nil, fmt.Errorf("read vector config for '%s': %w", name, err)
+	}
+
+	idx, err := NewVectorIndex(backend, m.GetDBPath(name), params, db, m.VectorWorker)
+	if err != nil {
+		return nil, fmt.Errorf("build vector index for '%s': %w", name, err)
+	}
+	if err := idx.Load(); err != nil {
+		return nil, fmt.Errorf("load vector index for '%s': %w", name, err)
+	}
+
+	m.mu.Lock()
+	m.vectorIndexes[name] = idx
+	m.mu.Unlock()
+	return idx, nil
+}
+
+// InsertChunk inserts a chunk row and its embedding into both SQLite and
+// dbName's VectorIndex inside a single SQLite transaction, rolling back if
+// the index Add fails so the two never drift apart.
+func (m *Manager) InsertChunk(dbName string, docID int64, chunkIndex int, startCharIdx, endCharIdx int, sectionPath, content string, embedding []float32) (int64, error) {
+	db, err := m.OpenCached(dbName)
+	if err != nil {
+		return 0, err
+	}
+	idx, err := m.VectorIndexFor(dbName)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO chunks (doc_id, chunk_index, start_char_idx, end_char_idx, section_path, content, embedding)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		docID, chunkIndex, startCharIdx, endCharIdx, sectionPath, content, models.Float32ToBytes(embedding),
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("insert chunk row: %w", err)
+	}
+
+	chunkID, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := idx.Add([]int64{chunkID}, [][]float32{embedding}); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("add chunk %d to vector index: %w", chunkID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		// idx.Add already succeeded and can't be undone, so the vector index
+		// now has a chunkID SQLite never actually committed. Log it loudly so
+		// a reconciliation pass (e.g. pruning index entries with no matching
+		// chunks row) has something to go on, rather than drifting silently.
+		log.Printf("[store] dbName=%s chunkID=%d committed to vector index but SQLite commit failed, stores have diverged: %v", dbName, chunkID, err)
+		return 0, err
+	}
+	return chunkID, nil
+}
+
+// CloseAll flushes and closes every cached VectorIndex, then closes every
+// cached *sql.DB handle. Intended for graceful shutdown.
+func (m *Manager) CloseAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, idx := range m.vectorIndexes {
+		idx.Save()
+		idx.Close()
+		delete(m.vectorIndexes, name)
+	}
+	for name, db := range m.openDBs {
+		db.Close()
+		delete(m.openDBs, name)
+	}
 }
 
 // GetDBPath returns the full path to a specific database folder
@@ -89,7 +261,7 @@ func (m *Manager) CreateDatabase(name string, description string) error {
 	defaults := config.CurrentDefaults
 
 	_, err = db.Exec(`
-		INSERT INTO config (key, value) VALUES 
+		INSERT INTO config (key, value) VALUES
 		('description', ?),
 		('created_at', ?),
 		('app_version', ?),
@@ -98,7 +270,9 @@ func (m *Manager) CreateDatabase(name string, description string) error {
 		('embed_dimension', ?),
 		('embed_context_length', ?),
 		('ner_model_id', ?),
-		('ner_model_version', ?)
+		('ner_model_version', ?),
+		('vector_backend', ?),
+		('vector_index_params', ?)
 	`,
 		description,
 		time.Now().Format(time.RFC3339),
@@ -109,6 +283,8 @@ func (m *Manager) CreateDatabase(name string, description string) error {
 		fmt.Sprintf("%d", defaults.EmbeddingModel.ContextLength),
 		defaults.NERModel.ID,
 		defaults.NERModel.Version,
+		defaults.VectorBackend,
+		defaults.VectorIndexParams,
 	)
 
 	return err
@@ -116,6 +292,17 @@ func (m *Manager) CreateDatabase(name string, description string) error {
 
 // DeleteDatabase removes the folder and all contents (SQL + Vectors)
 func (m *Manager) DeleteDatabase(name string) error {
+	m.mu.Lock()
+	if idx, ok := m.vectorIndexes[name]; ok {
+		idx.Close()
+		delete(m.vectorIndexes, name)
+	}
+	if db, ok := m.openDBs[name]; ok {
+		db.Close()
+		delete(m.openDBs, name)
+	}
+	m.mu.Unlock()
+
 	path := m.GetDBPath(name)
 
 	// Safety check: make sure we are deleting a directory inside our managed folder
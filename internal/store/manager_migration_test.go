@@ -0,0 +1,62 @@
+package store
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+)
+
+func newTestManager() *Manager {
+	return &Manager{
+		openDBs:       make(map[string]*sql.DB),
+		vectorIndexes: make(map[string]VectorIndex),
+		migrating:     make(map[string]bool),
+	}
+}
+
+func TestTryStartMigrationRejectsConcurrentRun(t *testing.T) {
+	m := newTestManager()
+
+	if !m.TryStartMigration("db1") {
+		t.Fatal("first TryStartMigration for db1 = false, want true")
+	}
+	if m.TryStartMigration("db1") {
+		t.Error("second concurrent TryStartMigration for db1 = true, want false (already running)")
+	}
+	// A different database is unaffected by db1's in-flight migration.
+	if !m.TryStartMigration("db2") {
+		t.Error("TryStartMigration for db2 = false, want true (unrelated database)")
+	}
+
+	m.FinishMigration("db1")
+	if !m.TryStartMigration("db1") {
+		t.Error("TryStartMigration for db1 after FinishMigration = false, want true")
+	}
+}
+
+func TestTryStartMigrationConcurrentCallersOnlyOneWins(t *testing.T) {
+	m := newTestManager()
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	wins := make(chan bool, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wins <- m.TryStartMigration("racy")
+		}()
+	}
+	wg.Wait()
+	close(wins)
+
+	won := 0
+	for w := range wins {
+		if w {
+			won++
+		}
+	}
+	if won != 1 {
+		t.Errorf("%d of %d concurrent TryStartMigration calls succeeded, want exactly 1", won, attempts)
+	}
+}
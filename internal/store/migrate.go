@@ -0,0 +1,113 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/config"
+)
+
+// ==========================================
+// MIGRATION PLANNING
+// ==========================================
+
+// MigrationPlan describes what must happen before a database's stored data
+// can be trusted again, derived by diffing its stamped config (written at
+// CreateDatabase time) against config.CurrentDefaults.
+type MigrationPlan struct {
+	ReembedAll bool     // embedding model/version/dimension changed: FAISS index must be rebuilt
+	RerunNER   bool     // NER model/version changed: existing graph is stale, not wrong
+	Rechunk    bool     // context length shrank: old chunks may now exceed the model's window
+	Reasons    []string // human-readable explanation per flag, for logging/API responses
+}
+
+// Required reports whether any migration step is needed.
+func (p MigrationPlan) Required() bool {
+	return p.ReembedAll || p.RerunNER || p.Rechunk
+}
+
+// Status reports p as one of config's MigrationStatus levels, for API
+// responses that just need a glanceable badge (DBInfoResponse.SchemaStatus)
+// rather than the full per-step plan.
+func (p MigrationPlan) Status() config.MigrationStatus {
+	switch {
+	case p.ReembedAll:
+		return config.StatusIncompatible
+	case p.RerunNER || p.Rechunk:
+		return config.StatusUpdateAvailable
+	default:
+		return config.StatusCompatible
+	}
+}
+
+// PlanMigration diffs a database's stamped config against the running
+// binary's defaults and reports what, if anything, needs to migrate.
+func PlanMigration(state config.DBState) MigrationPlan {
+	defaults := config.CurrentDefaults
+	var plan MigrationPlan
+
+	if state.EmbedID != defaults.EmbeddingModel.ID ||
+		state.EmbedVersion != defaults.EmbeddingModel.Version ||
+		state.EmbedDim != defaults.EmbeddingModel.Dimension {
+		plan.ReembedAll = true
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf(
+			"embedding model changed: %s@%s (dim %d) -> %s@%s (dim %d)",
+			state.EmbedID, state.EmbedVersion, state.EmbedDim,
+			defaults.EmbeddingModel.ID, defaults.EmbeddingModel.Version, defaults.EmbeddingModel.Dimension))
+	}
+
+	if state.NERID != defaults.NERModel.ID || state.NERVersion != defaults.NERModel.Version {
+		plan.RerunNER = true
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf(
+			"NER model changed: %s@%s -> %s@%s",
+			state.NERID, state.NERVersion, defaults.NERModel.ID, defaults.NERModel.Version))
+	}
+
+	if state.EmbedContextLength > 0 && state.EmbedContextLength > defaults.EmbeddingModel.ContextLength {
+		plan.Rechunk = true
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf(
+			"context length shrank: %d -> %d, existing chunks may now exceed the model's window",
+			state.EmbedContextLength, defaults.EmbeddingModel.ContextLength))
+	}
+
+	return plan
+}
+
+// OpenDatabase opens name's SQLite file and reports the MigrationPlan
+// required to bring it in line with config.CurrentDefaults. Callers should
+// refuse write operations (and, ideally, display the plan to the user)
+// whenever plan.Required() is true.
+func (m *Manager) OpenDatabase(name string) (*sql.DB, MigrationPlan, error) {
+	dbPath := filepath.Join(m.GetDBPath(name), "rag.db")
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, MigrationPlan{}, fmt.Errorf("database '%s' not found", name)
+	}
+
+	state, err := m.GetDBConfig(name)
+	if err != nil {
+		return nil, MigrationPlan{}, fmt.Errorf("failed to read stamped config: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, MigrationPlan{}, err
+	}
+
+	return db, PlanMigration(state), nil
+}
+
+// ==========================================
+// MIGRATION EXECUTION
+// ==========================================
+
+// MigrationProgress reports re-embed progress so callers can drive an SSE
+// stream the same way HandleBatchEvents does, or poll it back out via
+// Migrator.Status.
+type MigrationProgress struct {
+	Stage     string // "none", "running"/"embedding"/"resuming", "done", "error"
+	Processed int
+	Total     int
+	Error     string
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/config"
+)
+
+func matchingDBState() config.DBState {
+	d := config.CurrentDefaults
+	return config.DBState{
+		EmbedID:            d.EmbeddingModel.ID,
+		EmbedVersion:       d.EmbeddingModel.Version,
+		EmbedDim:           d.EmbeddingModel.Dimension,
+		EmbedContextLength: d.EmbeddingModel.ContextLength,
+		NERID:              d.NERModel.ID,
+		NERVersion:         d.NERModel.Version,
+	}
+}
+
+func TestPlanMigrationNoneRequiredWhenUpToDate(t *testing.T) {
+	plan := PlanMigration(matchingDBState())
+	if plan.Required() {
+		t.Errorf("plan = %+v, want nothing required for a state matching CurrentDefaults", plan)
+	}
+	if plan.Status() != config.StatusCompatible {
+		t.Errorf("Status() = %v, want StatusCompatible", plan.Status())
+	}
+}
+
+func TestPlanMigrationEmbeddingChangeForcesReembed(t *testing.T) {
+	state := matchingDBState()
+	state.EmbedVersion = "old-version"
+
+	plan := PlanMigration(state)
+	if !plan.ReembedAll {
+		t.Error("ReembedAll = false, want true for a changed embed version")
+	}
+	if !plan.Required() {
+		t.Error("Required() = false, want true")
+	}
+	if plan.Status() != config.StatusIncompatible {
+		t.Errorf("Status() = %v, want StatusIncompatible", plan.Status())
+	}
+	if len(plan.Reasons) != 1 {
+		t.Errorf("got %d reasons, want exactly 1", len(plan.Reasons))
+	}
+}
+
+func TestPlanMigrationNERChangeIsOptional(t *testing.T) {
+	state := matchingDBState()
+	state.NERVersion = "old-version"
+
+	plan := PlanMigration(state)
+	if !plan.RerunNER {
+		t.Error("RerunNER = false, want true for a changed NER version")
+	}
+	if plan.ReembedAll {
+		t.Error("ReembedAll = true, want false when only NER changed")
+	}
+	if plan.Status() != config.StatusUpdateAvailable {
+		t.Errorf("Status() = %v, want StatusUpdateAvailable", plan.Status())
+	}
+}
+
+func TestPlanMigrationContextLengthShrinkTriggersRechunk(t *testing.T) {
+	state := matchingDBState()
+	state.EmbedContextLength = config.CurrentDefaults.EmbeddingModel.ContextLength + 500
+
+	plan := PlanMigration(state)
+	if !plan.Rechunk {
+		t.Error("Rechunk = false, want true when the stamped context length exceeds the current default")
+	}
+	if plan.Status() != config.StatusUpdateAvailable {
+		t.Errorf("Status() = %v, want StatusUpdateAvailable", plan.Status())
+	}
+}
+
+func TestPlanMigrationZeroContextLengthIsNotARegression(t *testing.T) {
+	// A DB stamped before EmbedContextLength existed reads back as 0; that
+	// must not be treated as "shrank" relative to any positive default.
+	state := matchingDBState()
+	state.EmbedContextLength = 0
+
+	plan := PlanMigration(state)
+	if plan.Rechunk {
+		t.Error("Rechunk = true for a zero stamped context length, want false")
+	}
+}
+
+func TestPlanMigrationStatusPrefersIncompatibleOverOptional(t *testing.T) {
+	state := matchingDBState()
+	state.EmbedVersion = "old-version"
+	state.NERVersion = "old-version"
+
+	plan := PlanMigration(state)
+	if plan.Status() != config.StatusIncompatible {
+		t.Errorf("Status() = %v, want StatusIncompatible when both a mandatory and optional change are present", plan.Status())
+	}
+}
@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/config"
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+// config table keys the Migrator uses to persist resumable progress. Kept
+// distinct from the embed_*/ner_* keys GetDBConfig reads so a migration in
+// flight never looks like a stamped model change.
+const (
+	migrationStatusKey = "migration_status" // "running" | "done" | "error"
+	migrationCursorKey = "migration_cursor" // last chunk id whose embedding was rewritten
+	migrationTotalKey  = "migration_total"  // chunk count as of migration start
+	migrationErrorKey  = "migration_error"
+)
+
+// Migrator re-embeds every chunk in a database whose stamped config has
+// drifted from config.CurrentDefaults (config.StatusIncompatible) and
+// rebuilds its vector index at the new dimension. Progress is checkpointed
+// into the config table after every batch via migration_cursor, so a crash
+// mid-run resumes from the last completed chunk instead of starting over.
+type Migrator struct {
+	DB           *sql.DB
+	DBDir        string
+	EmbedWorker  ipc.Client
+	VectorWorker ipc.Client
+	BatchSize    int
+}
+
+// NewMigrator builds a Migrator for db, whose on-disk files (vectors.index)
+// live under dbDir.
+func NewMigrator(db *sql.DB, dbDir string, embedWorker, vectorWorker ipc.Client, batchSize int) *Migrator {
+	if batchSize <= 0 {
+		batchSize = 32
+	}
+	return &Migrator{DB: db, DBDir: dbDir, EmbedWorker: embedWorker, VectorWorker: vectorWorker, BatchSize: batchSize}
+}
+
+// Run walks every chunk with id > the persisted migration_cursor (0 on a
+// fresh start), re-embeds it in batches, rewrites its embedding BLOB, and
+// appends it to a new vector index built alongside the live one. Only once
+// every chunk has been re-added does it save and atomically swap the index
+// into place and stamp the new embed_* config. onProgress is called after
+// every batch; it may be nil.
+func (m *Migrator) Run(onProgress func(MigrationProgress)) error {
+	report := func(p MigrationProgress) {
+		if onProgress != nil {
+			onProgress(p)
+		}
+	}
+
+	state, err := m.readState()
+	if err != nil {
+		return fmt.Errorf("read migration state: %w", err)
+	}
+
+	indexPath := filepath.Join(m.DBDir, "vectors.index")
+	newIndexPath := indexPath + ".new"
+	dimension := config.CurrentDefaults.EmbeddingModel.Dimension
+
+	if state.cursor == 0 {
+		total, err := m.countChunks()
+		if err != nil {
+			return fmt.Errorf("count chunks: %w", err)
+		}
+		state.total = total
+		if err := m.writeState("running", 0, total, ""); err != nil {
+			return err
+		}
+
+		var initResp models.WorkerVectorResponse
+		if err := m.VectorWorker.Process(context.Background(), models.WorkerVectorCmd{Command: "init", Path: newIndexPath, Dimension: dimension}, &initResp); err != nil {
+			m.fail(err.Error(), report)
+			return fmt.Errorf("init fresh index: %w", err)
+		}
+		if initResp.Error != "" {
+			m.fail(initResp.Error, report)
+			return fmt.Errorf("init fresh index: %s", initResp.Error)
+		}
+	} else {
+		// Resuming: the partially-built index from the last run is still
+		// sitting at newIndexPath. Load it back in rather than starting over
+		// so the chunks already added before the crash aren't lost.
+		var loadResp models.WorkerVectorResponse
+		if err := m.VectorWorker.Process(context.Background(), models.WorkerVectorCmd{Command: "load", Path: newIndexPath}, &loadResp); err != nil {
+			m.fail(err.Error(), report)
+			return fmt.Errorf("load in-progress index: %w", err)
+		}
+		if loadResp.Error != "" {
+			m.fail(loadResp.Error, report)
+			return fmt.Errorf("load in-progress index: %s", loadResp.Error)
+		}
+		report(MigrationProgress{Stage: "resuming", Processed: int(state.cursor), Total: state.total})
+	}
+
+	rows, err := m.DB.Query(`SELECT id, content FROM chunks WHERE id > ? ORDER BY id`, state.cursor)
+	if err != nil {
+		return fmt.Errorf("list remaining chunks: %w", err)
+	}
+	var ids []int64
+	var texts []string
+	for rows.Next() {
+		var id int64
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		texts = append(texts, content)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	processed := state.cursor
+	processedBeforeRun := state.total - len(ids)
+	for start := 0; start < len(ids); start += m.BatchSize {
+		end := start + m.BatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batchIDs := ids[start:end]
+
+		var embedResp models.WorkerEmbedResponse
+		if err := m.EmbedWorker.Process(context.Background(), models.WorkerEmbedRequest{Texts: texts[start:end], TaskType: "retrieval_document"}, &embedResp); err != nil {
+			m.fail(err.Error(), report)
+			return fmt.Errorf("embed batch ending at chunk %d: %w", batchIDs[len(batchIDs)-1], err)
+		}
+		if embedResp.Error != "" {
+			m.fail(embedResp.Error, report)
+			return fmt.Errorf("embed batch ending at chunk %d: %s", batchIDs[len(batchIDs)-1], embedResp.Error)
+		}
+
+		tx, err := m.DB.Begin()
+		if err != nil {
+			return err
+		}
+		stmt, err := tx.Prepare(`UPDATE chunks SET embedding = ? WHERE id = ?`)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		for i, vec := range embedResp.Vectors {
+			if _, err := stmt.Exec(models.Float32ToBytes(vec), batchIDs[i]); err != nil {
+				stmt.Close()
+				tx.Rollback()
+				return fmt.Errorf("rewrite embedding for chunk %d: %w", batchIDs[i], err)
+			}
+		}
+		stmt.Close()
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		var addResp models.WorkerVectorResponse
+		if err := m.VectorWorker.Process(context.Background(), models.WorkerVectorCmd{Command: "add", Path: newIndexPath, Vectors: embedResp.Vectors, Ids: batchIDs}, &addResp); err != nil {
+			m.fail(err.Error(), report)
+			return fmt.Errorf("add batch ending at chunk %d to index: %w", batchIDs[len(batchIDs)-1], err)
+		}
+		if addResp.Error != "" {
+			m.fail(addResp.Error, report)
+			return fmt.Errorf("add batch ending at chunk %d to index: %s", batchIDs[len(batchIDs)-1], addResp.Error)
+		}
+
+		processed = batchIDs[len(batchIDs)-1]
+		if err := m.writeState("running", processed, state.total, ""); err != nil {
+			return err
+		}
+		report(MigrationProgress{Stage: "embedding", Processed: processedBeforeRun + end, Total: state.total})
+	}
+
+	var saveResp models.WorkerVectorResponse
+	if err := m.VectorWorker.Process(context.Background(), models.WorkerVectorCmd{Command: "save", Path: newIndexPath}, &saveResp); err != nil {
+		m.fail(err.Error(), report)
+		return fmt.Errorf("save new index: %w", err)
+	}
+
+	// Atomic swap: only replace the live index once the rebuild succeeded.
+	if err := os.Rename(newIndexPath, indexPath); err != nil {
+		m.fail(err.Error(), report)
+		return fmt.Errorf("swap index into place: %w", err)
+	}
+
+	if err := stampEmbedConfig(m.DB); err != nil {
+		m.fail(err.Error(), report)
+		return fmt.Errorf("rewrite stamped embed config: %w", err)
+	}
+	if err := m.writeState("done", processed, state.total, ""); err != nil {
+		return err
+	}
+
+	report(MigrationProgress{Stage: "done", Processed: state.total, Total: state.total})
+	return nil
+}
+
+// ReadMigrationStatus reports db's persisted migration progress without
+// requiring a fully-constructed Migrator (no worker pools needed to just
+// read status back out of the config table).
+func ReadMigrationStatus(db *sql.DB) (MigrationProgress, error) {
+	m := &Migrator{DB: db}
+	return m.Status()
+}
+
+// Status reports the persisted progress of this database's migration,
+// whether or not the Migrator instance that ran it is still alive — it's
+// read straight back out of the config table, so GET /api/v1/db/migrate/status
+// works even if the migration was kicked off by a different request.
+func (m *Migrator) Status() (MigrationProgress, error) {
+	state, err := m.readState()
+	if err != nil {
+		return MigrationProgress{}, err
+	}
+	stage := state.status
+	if stage == "" {
+		stage = "none"
+	}
+	return MigrationProgress{Stage: stage, Processed: int(state.cursor), Total: state.total, Error: state.errMsg}, nil
+}
+
+type migrationState struct {
+	status string
+	cursor int64
+	total  int
+	errMsg string
+}
+
+func (m *Migrator) readState() (migrationState, error) {
+	var state migrationState
+	rows, err := m.DB.Query(`SELECT key, value FROM config WHERE key IN (?, ?, ?, ?)`,
+		migrationStatusKey, migrationCursorKey, migrationTotalKey, migrationErrorKey)
+	if err != nil {
+		return state, err
+	}
+	defer rows.Close()
+
+	kv := make(map[string]string)
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err == nil {
+			kv[k] = v
+		}
+	}
+
+	state.status = kv[migrationStatusKey]
+	state.errMsg = kv[migrationErrorKey]
+	if c, err := strconv.ParseInt(kv[migrationCursorKey], 10, 64); err == nil {
+		state.cursor = c
+	}
+	if t, err := strconv.Atoi(kv[migrationTotalKey]); err == nil {
+		state.total = t
+	}
+	return state, nil
+}
+
+func (m *Migrator) writeState(status string, cursor int64, total int, errMsg string) error {
+	_, err := m.DB.Exec(`
+		INSERT INTO config (key, value) VALUES
+		(?, ?), (?, ?), (?, ?), (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`,
+		migrationStatusKey, status,
+		migrationCursorKey, strconv.FormatInt(cursor, 10),
+		migrationTotalKey, strconv.Itoa(total),
+		migrationErrorKey, errMsg,
+	)
+	return err
+}
+
+func (m *Migrator) fail(msg string, report func(MigrationProgress)) {
+	state, _ := m.readState()
+	m.writeState("error", state.cursor, state.total, msg)
+	report(MigrationProgress{Stage: "error", Processed: int(state.cursor), Total: state.total, Error: msg})
+}
+
+func (m *Migrator) countChunks() (int, error) {
+	var total int
+	err := m.DB.QueryRow(`SELECT COUNT(*) FROM chunks`).Scan(&total)
+	return total, err
+}
+
+// stampEmbedConfig rewrites the config table's embed_* keys to match
+// config.CurrentDefaults. Only called after a re-embed migration has fully
+// succeeded and the new index is live.
+func stampEmbedConfig(db *sql.DB) error {
+	defaults := config.CurrentDefaults
+	_, err := db.Exec(`
+		INSERT INTO config (key, value) VALUES
+		('embed_model_id', ?),
+		('embed_model_version', ?),
+		('embed_dimension', ?),
+		('embed_context_length', ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`,
+		defaults.EmbeddingModel.ID,
+		defaults.EmbeddingModel.Version,
+		fmt.Sprintf("%d", defaults.EmbeddingModel.Dimension),
+		fmt.Sprintf("%d", defaults.EmbeddingModel.ContextLength),
+	)
+	return err
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newMigrationTestDB(t *testing.T, chunkCount int) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open in-memory db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(SchemaSQL); err != nil {
+		t.Fatalf("apply schema: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO documents (id, name) VALUES (1, 'doc')`); err != nil {
+		t.Fatalf("seed document: %v", err)
+	}
+	for i := 0; i < chunkCount; i++ {
+		if _, err := db.Exec(`INSERT INTO chunks (doc_id, chunk_index, content) VALUES (1, ?, ?)`, i, fmt.Sprintf("chunk %d", i)); err != nil {
+			t.Fatalf("seed chunk %d: %v", i, err)
+		}
+	}
+	return db
+}
+
+// fakeWorkerClient answers ipc.Client.Process by copying a canned response
+// into resp, optionally counting how many times it was called for a given
+// WorkerVectorCmd.Command.
+type fakeWorkerClient struct {
+	embedResp  models.WorkerEmbedResponse
+	embedErr   error
+	vectorResp models.WorkerVectorResponse
+	vectorErr  error
+	calls      map[string]int
+}
+
+func (f *fakeWorkerClient) Process(ctx context.Context, req interface{}, resp interface{}) error {
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	switch r := req.(type) {
+	case models.WorkerEmbedRequest:
+		f.calls["embed"]++
+		if f.embedErr != nil {
+			return f.embedErr
+		}
+		out := resp.(*models.WorkerEmbedResponse)
+		vectors := make([][]float32, len(r.Texts))
+		for i := range r.Texts {
+			vectors[i] = []float32{1, 2, 3}
+		}
+		out.Vectors = vectors
+		return nil
+	case models.WorkerVectorCmd:
+		f.calls[r.Command]++
+		if f.vectorErr != nil {
+			return f.vectorErr
+		}
+		if r.Command == "init" || r.Command == "save" {
+			// Run swaps newIndexPath into place on success, so a real worker
+			// would have left a file there; stand in for that.
+			if err := os.WriteFile(r.Path, []byte("fake index"), 0o644); err != nil {
+				return err
+			}
+		}
+		out := resp.(*models.WorkerVectorResponse)
+		*out = f.vectorResp
+		return nil
+	default:
+		return fmt.Errorf("fakeWorkerClient: unexpected request type %T", req)
+	}
+}
+
+func TestMigratorRunReembedsEveryChunk(t *testing.T) {
+	db := newMigrationTestDB(t, 5)
+	embed := &fakeWorkerClient{}
+	vector := &fakeWorkerClient{}
+	m := NewMigrator(db, t.TempDir(), embed, vector, 2)
+
+	var progress []MigrationProgress
+	if err := m.Run(func(p MigrationProgress) { progress = append(progress, p) }); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if embed.calls["embed"] != 3 { // 5 chunks at batch size 2 -> 3 batches
+		t.Errorf("embed worker called %d times, want 3 (ceil(5/2))", embed.calls["embed"])
+	}
+	if vector.calls["init"] != 1 {
+		t.Errorf("vector worker 'init' called %d times, want 1", vector.calls["init"])
+	}
+	if vector.calls["add"] != 3 {
+		t.Errorf("vector worker 'add' called %d times, want 3", vector.calls["add"])
+	}
+	if vector.calls["save"] != 1 {
+		t.Errorf("vector worker 'save' called %d times, want 1", vector.calls["save"])
+	}
+
+	if len(progress) == 0 {
+		t.Fatal("onProgress was never called")
+	}
+	last := progress[len(progress)-1]
+	if last.Stage != "done" || last.Processed != 5 || last.Total != 5 {
+		t.Errorf("final progress = %+v, want {Stage: done, Processed: 5, Total: 5}", last)
+	}
+
+	status, err := ReadMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("ReadMigrationStatus returned error: %v", err)
+	}
+	if status.Stage != "done" || status.Processed != 5 || status.Total != 5 {
+		t.Errorf("persisted status = %+v, want {Stage: done, Processed: 5, Total: 5}", status)
+	}
+
+	var rewritten int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM chunks WHERE embedding IS NOT NULL`).Scan(&rewritten); err != nil {
+		t.Fatalf("count rewritten embeddings: %v", err)
+	}
+	if rewritten != 5 {
+		t.Errorf("got %d chunks with a rewritten embedding, want 5", rewritten)
+	}
+}
+
+func TestMigratorRunResumesFromCursor(t *testing.T) {
+	db := newMigrationTestDB(t, 4)
+	embed := &fakeWorkerClient{}
+	vector := &fakeWorkerClient{}
+	m := NewMigrator(db, t.TempDir(), embed, vector, 10)
+
+	// Simulate a prior run that got through chunk 2 before crashing: cursor
+	// is the id of the last chunk successfully rewritten (chunk ids start
+	// at 1 here since the table is otherwise empty).
+	if err := m.writeState("running", 2, 4, ""); err != nil {
+		t.Fatalf("seed migration state: %v", err)
+	}
+
+	var sawResuming bool
+	if err := m.Run(func(p MigrationProgress) {
+		if p.Stage == "resuming" {
+			sawResuming = true
+		}
+	}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !sawResuming {
+		t.Error("Run with a nonzero cursor never reported a 'resuming' stage")
+	}
+	// Only the 2 chunks after the cursor should have been re-embedded.
+	if embed.calls["embed"] != 1 {
+		t.Errorf("embed worker called %d times, want 1 (one batch covering chunks 3-4)", embed.calls["embed"])
+	}
+	if vector.calls["init"] != 0 {
+		t.Errorf("vector worker 'init' called %d times, want 0 (resume should 'load' instead)", vector.calls["init"])
+	}
+	if vector.calls["load"] != 1 {
+		t.Errorf("vector worker 'load' called %d times, want 1", vector.calls["load"])
+	}
+}
+
+func TestMigratorRunPersistsErrorOnEmbedFailure(t *testing.T) {
+	db := newMigrationTestDB(t, 3)
+	embed := &fakeWorkerClient{embedErr: fmt.Errorf("worker crashed")}
+	vector := &fakeWorkerClient{}
+	m := NewMigrator(db, t.TempDir(), embed, vector, 10)
+
+	var sawError bool
+	err := m.Run(func(p MigrationProgress) {
+		if p.Stage == "error" {
+			sawError = true
+		}
+	})
+	if err == nil {
+		t.Fatal("Run returned no error when the embed worker failed")
+	}
+	if !sawError {
+		t.Error("onProgress was never called with Stage 'error'")
+	}
+
+	status, statusErr := ReadMigrationStatus(db)
+	if statusErr != nil {
+		t.Fatalf("ReadMigrationStatus returned error: %v", statusErr)
+	}
+	if status.Stage != "error" || status.Error == "" {
+		t.Errorf("persisted status = %+v, want Stage 'error' with a non-empty Error message", status)
+	}
+}
+
+func TestMigratorRunNoChunks(t *testing.T) {
+	db := newMigrationTestDB(t, 0)
+	embed := &fakeWorkerClient{}
+	vector := &fakeWorkerClient{}
+	m := NewMigrator(db, t.TempDir(), embed, vector, 10)
+
+	if err := m.Run(nil); err != nil {
+		t.Fatalf("Run on an empty chunks table returned error: %v", err)
+	}
+	status, err := ReadMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("ReadMigrationStatus returned error: %v", err)
+	}
+	if status.Stage != "done" || status.Total != 0 {
+		t.Errorf("status = %+v, want {Stage: done, Total: 0}", status)
+	}
+}
+
+func TestNewMigratorDefaultsBatchSize(t *testing.T) {
+	m := NewMigrator(nil, "", nil, nil, 0)
+	if m.BatchSize != 32 {
+		t.Errorf("BatchSize = %d, want 32 default", m.BatchSize)
+	}
+	m = NewMigrator(nil, "", nil, nil, -5)
+	if m.BatchSize != 32 {
+		t.Errorf("BatchSize = %d, want 32 default for a negative input", m.BatchSize)
+	}
+}
@@ -55,6 +55,7 @@ CREATE TABLE IF NOT EXISTS chunks (
     chunk_index INTEGER,              -- 0, 1, 2... order in doc
     start_char_idx INTEGER,           -- Start index in original text
     end_char_idx INTEGER,             -- End index in original text
+    section_path TEXT,                -- Heading breadcrumb, e.g. "Chapter 3 > Results > Table 2"
     content TEXT,                     -- The plain text of this chunk
     embedding BLOB,                   -- Vector (float32 array bytes)
     FOREIGN KEY(doc_id) REFERENCES documents(id) ON DELETE CASCADE
@@ -107,10 +108,25 @@ CREATE TABLE IF NOT EXISTS chunk_entities (
     entity_id INTEGER,
     local_frequency INTEGER DEFAULT 1,
     offsets TEXT,                     -- JSON: [[start, end], [start, end]] relative to chunk
+    confidence REAL DEFAULT 1,        -- NER worker's score for this mention; varies per occurrence, so it's per-row, not per-entity
     FOREIGN KEY(chunk_id) REFERENCES chunks(id) ON DELETE CASCADE,
     FOREIGN KEY(entity_id) REFERENCES entities(id) ON DELETE CASCADE
 );
 
+-- Entity Edges: co-occurrence graph. A row exists for every pair of
+-- canonical entities that appear together in at least one chunk; weight
+-- accumulates every time the pair co-occurs again.
+CREATE TABLE IF NOT EXISTS entity_edges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    src_entity_id INTEGER,
+    dst_entity_id INTEGER,             -- src_entity_id < dst_entity_id, always
+    weight REAL DEFAULT 1,             -- accumulated co-occurrence strength, scaled by NER confidence
+    last_chunk_id INTEGER,             -- most recent chunk that contributed to this edge
+    UNIQUE(src_entity_id, dst_entity_id),
+    FOREIGN KEY(src_entity_id) REFERENCES entities(id) ON DELETE CASCADE,
+    FOREIGN KEY(dst_entity_id) REFERENCES entities(id) ON DELETE CASCADE
+);
+
 -- Chunk Labels: "Macro-Heatmap"
 CREATE TABLE IF NOT EXISTS chunk_labels (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -145,6 +161,8 @@ CREATE INDEX IF NOT EXISTS idx_chunk_entities_entity ON chunk_entities(entity_id
 CREATE INDEX IF NOT EXISTS idx_chunk_entities_chunk ON chunk_entities(chunk_id);
 CREATE INDEX IF NOT EXISTS idx_chunk_labels_label ON chunk_labels(label_id);
 CREATE INDEX IF NOT EXISTS idx_chunk_labels_chunk ON chunk_labels(chunk_id);
+CREATE INDEX IF NOT EXISTS idx_entity_edges_src ON entity_edges(src_entity_id);
+CREATE INDEX IF NOT EXISTS idx_entity_edges_dst ON entity_edges(dst_entity_id);
 CREATE INDEX IF NOT EXISTS idx_request_logs_type ON request_logs(type);
 CREATE INDEX IF NOT EXISTS idx_request_logs_date ON request_logs(created_at);
 `
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+// faissIndexFile is where FAISSWorker tells the Python sidecar to
+// load/save its index, relative to a database's directory. Deliberately
+// distinct from the "vectors.index"/".new" pair store.Migrator uses for
+// its own re-embed-time worker protocol (models.WorkerVectorCmd) — the two
+// speak different wire formats and must never collide on one file.
+const faissIndexFile = "vectors.faiss"
+
+// FAISSWorker is a VectorIndex that proxies every call to a Python sidecar
+// over the existing models.VectorDBRequest/VectorDBResponse worker
+// protocol (load/add/search/save).
+type FAISSWorker struct {
+	worker ipc.Client
+	dbPath string // full path to the on-disk FAISS index for this database
+}
+
+// NewFAISSWorker builds a FAISSWorker whose index file lives under dbDir.
+func NewFAISSWorker(worker ipc.Client, dbDir string) *FAISSWorker {
+	return &FAISSWorker{worker: worker, dbPath: filepath.Join(dbDir, faissIndexFile)}
+}
+
+func (f *FAISSWorker) Load() error {
+	var resp models.VectorDBResponse
+	if err := f.worker.Process(context.Background(), models.VectorDBRequest{Command: "load", DbPath: f.dbPath}, &resp); err != nil {
+		return fmt.Errorf("faiss worker load: %w", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("faiss worker load: %s", resp.Error)
+	}
+	return nil
+}
+
+func (f *FAISSWorker) Add(ids []int64, vectors [][]float32) error {
+	var resp models.VectorDBResponse
+	req := models.VectorDBRequest{Command: "add", DbPath: f.dbPath, Ids: ids, Vectors: vectors}
+	if err := f.worker.Process(context.Background(), req, &resp); err != nil {
+		return fmt.Errorf("faiss worker add: %w", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("faiss worker add: %s", resp.Error)
+	}
+	return nil
+}
+
+func (f *FAISSWorker) Search(vec []float32, topK int, allowedIDs []int64) ([]int64, []float32, error) {
+	var resp models.VectorDBResponse
+	req := models.VectorDBRequest{Command: "search", DbPath: f.dbPath, QueryVec: vec, TopK: topK}
+	if err := f.worker.Process(context.Background(), req, &resp); err != nil {
+		return nil, nil, fmt.Errorf("faiss worker search: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, nil, fmt.Errorf("faiss worker search: %s", resp.Error)
+	}
+
+	// FAISS itself has no notion of allowedIDs, so the hybrid tag/label
+	// pre-filter is applied on the results it returns rather than pushed
+	// into the search call.
+	if allowedIDs == nil {
+		return resp.Results, resp.Scores, nil
+	}
+	allowed := make(map[int64]bool, len(allowedIDs))
+	for _, id := range allowedIDs {
+		allowed[id] = true
+	}
+	var ids []int64
+	var scores []float32
+	for i, id := range resp.Results {
+		if allowed[id] {
+			ids = append(ids, id)
+			scores = append(scores, resp.Scores[i])
+		}
+	}
+	return ids, scores, nil
+}
+
+func (f *FAISSWorker) Save() error {
+	var resp models.VectorDBResponse
+	if err := f.worker.Process(context.Background(), models.VectorDBRequest{Command: "save", DbPath: f.dbPath}, &resp); err != nil {
+		return fmt.Errorf("faiss worker save: %w", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("faiss worker save: %s", resp.Error)
+	}
+	return nil
+}
+
+// Close is a no-op: the worker process/pool is owned and shut down by
+// whoever constructed it (main.go), not by individual FAISSWorker callers.
+func (f *FAISSWorker) Close() error { return nil }
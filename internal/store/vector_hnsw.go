@@ -0,0 +1,132 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/coder/hnsw"
+)
+
+// hnswIndexFile is where HNSWNative persists its graph, relative to a
+// database's directory.
+const hnswIndexFile = "vectors.hnsw"
+
+// HNSWNative is a VectorIndex backed by a pure-Go HNSW graph — no external
+// process, no CGo — persisted to <dbdir>/vectors.hnsw between Load/Save.
+// Embeddings are expected to already be normalized (the embedding worker
+// does this), so the library's default distance function ranks the same
+// as cosine similarity would.
+type HNSWNative struct {
+	path  string
+	graph *hnsw.Graph[int64]
+}
+
+// NewHNSWNative builds an HNSWNative whose graph file lives under dbDir.
+// params is the raw vector_index_params config string; currently unused
+// (M/efSearch stay at the library's defaults) but threaded through now so
+// tuning them later doesn't need a VectorIndex interface change.
+func NewHNSWNative(dbDir, params string) (*HNSWNative, error) {
+	return &HNSWNative{path: filepath.Join(dbDir, hnswIndexFile)}, nil
+}
+
+// Load reads the persisted graph if one exists, or starts a fresh empty
+// graph for a brand-new database.
+func (h *HNSWNative) Load() error {
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		h.graph = hnsw.NewGraph[int64]()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open hnsw index: %w", err)
+	}
+	defer f.Close()
+
+	graph := hnsw.NewGraph[int64]()
+	if err := graph.Import(f); err != nil {
+		return fmt.Errorf("import hnsw index: %w", err)
+	}
+	h.graph = graph
+	return nil
+}
+
+// Add inserts vectors into the in-memory graph; Save must be called
+// afterwards for it to survive a restart.
+func (h *HNSWNative) Add(ids []int64, vectors [][]float32) error {
+	if h.graph == nil {
+		h.graph = hnsw.NewGraph[int64]()
+	}
+	nodes := make([]hnsw.Node[int64], len(ids))
+	for i, id := range ids {
+		nodes[i] = hnsw.Node[int64]{Key: id, Value: vectors[i]}
+	}
+	h.graph.Add(nodes...)
+	return nil
+}
+
+// Search walks the graph for vec's nearest neighbors. HNSW has no native
+// pre-filter, so when allowedIDs is set we over-fetch and filter down in
+// Go, the same tradeoff FAISSWorker makes.
+func (h *HNSWNative) Search(vec []float32, topK int, allowedIDs []int64) ([]int64, []float32, error) {
+	if h.graph == nil {
+		return nil, nil, nil
+	}
+
+	fetch := topK
+	if allowedIDs != nil && len(allowedIDs)*2 > fetch {
+		fetch = len(allowedIDs) * 2
+	}
+
+	var allowed map[int64]bool
+	if allowedIDs != nil {
+		allowed = make(map[int64]bool, len(allowedIDs))
+		for _, id := range allowedIDs {
+			allowed[id] = true
+		}
+	}
+
+	results := h.graph.Search(vec, fetch)
+	ids := make([]int64, 0, len(results))
+	scores := make([]float32, 0, len(results))
+	for _, r := range results {
+		if allowed != nil && !allowed[r.Key] {
+			continue
+		}
+		ids = append(ids, r.Key)
+		scores = append(scores, cosineSimilarity(vec, r.Value))
+		if len(ids) == topK {
+			break
+		}
+	}
+	return ids, scores, nil
+}
+
+// Save atomically writes the graph to disk: export to a temp file, then
+// rename over the live index so a crash mid-export can't corrupt it.
+func (h *HNSWNative) Save() error {
+	if h.graph == nil {
+		return nil
+	}
+
+	tmp := h.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create hnsw index: %w", err)
+	}
+	if err := h.graph.Export(f); err != nil {
+		f.Close()
+		return fmt.Errorf("export hnsw index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// Close drops the in-memory graph. Callers that want durability call Save
+// first.
+func (h *HNSWNative) Close() error {
+	h.graph = nil
+	return nil
+}
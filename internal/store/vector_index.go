@@ -0,0 +1,91 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/ipc"
+)
+
+// config table keys stamped at CreateDatabase time describing which
+// VectorIndex backend a database's on-disk index was built with, and any
+// backend-specific tuning params (e.g. HNSW's M/efConstruction, as a raw
+// JSON string). Both are read back by readVectorConfig so opening a
+// database always resolves the same backend it was created with, even if
+// config.CurrentDefaults.VectorBackend has since changed for new ones.
+const (
+	vectorBackendKey     = "vector_backend"
+	vectorIndexParamsKey = "vector_index_params"
+)
+
+// VectorIndex is the interface every ANN/brute-force backend implements so
+// search handlers and chunk insertion can stay backend-agnostic.
+type VectorIndex interface {
+	// Load prepares the index for use, reading any on-disk state. Called
+	// once when a database is opened; a no-op for SQLiteBrute, which has
+	// no on-disk structure separate from chunks.embedding.
+	Load() error
+
+	// Add inserts vectors for ids. InsertChunk calls this inside the same
+	// SQLite transaction as the chunk row insert and rolls that
+	// transaction back if Add fails, so the two never drift apart.
+	Add(ids []int64, vectors [][]float32) error
+
+	// Search returns the topK ids (and their similarity scores) nearest to
+	// vec, highest score first. If allowedIDs is non-nil, results are
+	// restricted to that set — the hybrid tag/label pre-filter fetched
+	// from SQLite before the ANN search runs.
+	Search(vec []float32, topK int, allowedIDs []int64) (ids []int64, scores []float32, err error)
+
+	// Save flushes the index to disk so a future Load picks up every Add
+	// since the last Save. A no-op for SQLiteBrute.
+	Save() error
+
+	// Close releases resources (file handles, worker connections).
+	// Callers that want durability call Save first.
+	Close() error
+}
+
+// NewVectorIndex builds the VectorIndex backend named by backend for the
+// database whose files live under dbDir. db is required for the
+// "sqlite_brute" backend (it scans chunks.embedding directly) and
+// vectorWorker is required for "faiss" (it proxies to a Python sidecar);
+// pass nil for whichever the chosen backend doesn't need.
+func NewVectorIndex(backend, dbDir, params string, db *sql.DB, vectorWorker ipc.Client) (VectorIndex, error) {
+	switch backend {
+	case "", "sqlite_brute":
+		return NewSQLiteBrute(db), nil
+	case "faiss":
+		if vectorWorker == nil {
+			return nil, fmt.Errorf("faiss vector backend requires a configured vector worker pool")
+		}
+		return NewFAISSWorker(vectorWorker, dbDir), nil
+	case "hnsw":
+		return NewHNSWNative(dbDir, params)
+	default:
+		return nil, fmt.Errorf("unknown vector_backend %q", backend)
+	}
+}
+
+// readVectorConfig reads the vector_backend/vector_index_params keys
+// stamped into db's config table at CreateDatabase time.
+func readVectorConfig(db *sql.DB) (backend, params string, err error) {
+	rows, err := db.Query(`SELECT key, value FROM config WHERE key IN (?, ?)`, vectorBackendKey, vectorIndexParamsKey)
+	if err != nil {
+		return "", "", err
+	}
+	defer rows.Close()
+
+	kv := make(map[string]string)
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return "", "", err
+		}
+		kv[k] = v
+	}
+	if err := rows.Err(); err != nil {
+		return "", "", err
+	}
+	return kv[vectorBackendKey], kv[vectorIndexParamsKey], nil
+}
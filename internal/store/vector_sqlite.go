@@ -0,0 +1,113 @@
+package store
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/GonzoDMX/rag-anywhere/internal/models"
+)
+
+// SQLiteBrute is the default VectorIndex: no separate on-disk structure,
+// just a cosine-similarity scan of chunks.embedding on every Search. Fine
+// for the small databases most users start with; NewVectorIndex picks
+// FAISSWorker or HNSWNative instead once vector_backend says so.
+type SQLiteBrute struct {
+	db *sql.DB
+}
+
+// NewSQLiteBrute builds a SQLiteBrute backed by db.
+func NewSQLiteBrute(db *sql.DB) *SQLiteBrute {
+	return &SQLiteBrute{db: db}
+}
+
+// Load is a no-op: there's no separate index file, the vectors already
+// live in chunks.embedding.
+func (s *SQLiteBrute) Load() error { return nil }
+
+// Add is a no-op: InsertChunk already wrote the embedding into the chunks
+// row within the same transaction, so there's nothing extra to persist.
+func (s *SQLiteBrute) Add(ids []int64, vectors [][]float32) error { return nil }
+
+// Search scans every embedded chunk (optionally restricted to allowedIDs)
+// and returns the topK by cosine similarity.
+func (s *SQLiteBrute) Search(vec []float32, topK int, allowedIDs []int64) ([]int64, []float32, error) {
+	query := `SELECT id, embedding FROM chunks WHERE embedding IS NOT NULL`
+	var args []interface{}
+
+	if allowedIDs != nil {
+		if len(allowedIDs) == 0 {
+			return nil, nil, nil
+		}
+		placeholders := make([]string, len(allowedIDs))
+		for i, id := range allowedIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += ` AND id IN (` + strings.Join(placeholders, ",") + `)`
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	type scored struct {
+		id    int64
+		score float32
+	}
+	var hits []scored
+	for rows.Next() {
+		var id int64
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, nil, err
+		}
+		hits = append(hits, scored{id: id, score: cosineSimilarity(vec, models.BytesToFloat32(blob))})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	if topK > 0 && len(hits) > topK {
+		hits = hits[:topK]
+	}
+
+	ids := make([]int64, len(hits))
+	scores := make([]float32, len(hits))
+	for i, h := range hits {
+		ids[i] = h.id
+		scores[i] = h.score
+	}
+	return ids, scores, nil
+}
+
+// Save is a no-op: see Add.
+func (s *SQLiteBrute) Save() error { return nil }
+
+// Close is a no-op: SQLiteBrute holds no resources of its own (the *sql.DB
+// it scans is owned and closed by whoever opened the database).
+func (s *SQLiteBrute) Close() error { return nil }
+
+// cosineSimilarity returns the cosine similarity of a and b, or -1 if
+// they're not the same dimension (a mismatch that should only happen
+// mid re-embed, when callers should be blocked from searching anyway —
+// see rejectIfDBUnavailable).
+func cosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return -1
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}